@@ -0,0 +1,248 @@
+package flargs
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ExpandArguments applies [Environment.ExpandVars] to each element of Arguments, in place,
+// up to the "--" terminator. The terminator and everything after it are left untouched.
+func (e *Environment) ExpandArguments() {
+	for i, arg := range e.Arguments {
+		if arg == "--" {
+			return
+		}
+		e.Arguments[i] = e.ExpandVars(arg)
+	}
+}
+
+// ExpandAllArguments is [Environment.ExpandArguments], but expands what follows the "--" terminator too
+func (e *Environment) ExpandAllArguments() {
+	for i, arg := range e.Arguments {
+		e.Arguments[i] = e.ExpandVars(arg)
+	}
+}
+
+// args returns Arguments without the program name
+func (e Environment) args() []string {
+	if len(e.Arguments) == 0 {
+		return nil
+	}
+	return e.Arguments[1:]
+}
+
+// hasFlag reports whether any of names appears in Arguments before the "--" terminator.
+// Names are given with their dashes, ex: "-q", "--quiet".
+func (e Environment) hasFlag(names ...string) bool {
+	for _, arg := range e.args() {
+		if arg == "--" {
+			return false
+		}
+		for _, name := range names {
+			if arg == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flagValue returns the value of the first of names found in Arguments before the "--" terminator,
+// given either as "--name value" or "--name=value".
+func (e Environment) flagValue(names ...string) (string, bool) {
+	args := e.args()
+	for i, arg := range args {
+		if arg == "--" {
+			return "", false
+		}
+		for _, name := range names {
+			if arg == name && i+1 < len(args) {
+				return args[i+1], true
+			}
+			if value, found := strings.CutPrefix(arg, name+"="); found {
+				return value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// maxResponseFileDepth bounds how deeply response files may include one another
+const maxResponseFileDepth = 8
+
+// ExpandResponseFiles replaces each "@file" argument with the arguments inside file, read from the Filesystem.
+// Arguments in a file are separated by whitespace or newlines, and may be quoted.
+// Response files may name other response files, up to a fixed depth.
+// The program name and anything after "--" are left alone.
+func (e *Environment) ExpandResponseFiles() error {
+	if len(e.Arguments) == 0 {
+		return nil
+	}
+	expanded, err := e.expandResponseFiles(e.Arguments[1:], 0)
+	if err != nil {
+		return err
+	}
+	e.Arguments = append(e.Arguments[:1:1], expanded...)
+	return nil
+}
+
+func (e Environment) expandResponseFiles(args []string, depth int) ([]string, error) {
+	var out []string
+	for i, arg := range args {
+		if arg == "--" {
+			return append(out, args[i:]...), nil
+		}
+		name, isResponseFile := strings.CutPrefix(arg, "@")
+		if !isResponseFile || name == "" {
+			out = append(out, arg)
+			continue
+		}
+		if depth >= maxResponseFileDepth {
+			return nil, fmt.Errorf("response files nested more than %d deep at %s", maxResponseFileDepth, arg)
+		}
+		data, err := e.Filesystem.ReadFile(e.ResolvePath(name))
+		if err != nil {
+			return nil, err
+		}
+		inner, err := splitArgs(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		inner, err = e.expandResponseFiles(inner, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, inner...)
+	}
+	return out, nil
+}
+
+// splitArgs splits s into arguments the way gcc and MSVC read a response file.
+// Whitespace separates, and single or double quotes group. Single quotes preserve everything.
+// A backslash is literal, so Windows paths survive, unless a run of them comes before a quote a backslash could escape:
+// then each pair becomes one backslash, and an odd one out makes the quote literal.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\\':
+			inArg = true
+			n := 1
+			for i+n < len(runes) && runes[i+n] == '\\' {
+				n++
+			}
+			//	inside double quotes, only a double quote can be escaped
+			next := rune(0)
+			if i+n < len(runes) {
+				next = runes[i+n]
+			}
+			if next != '"' && (next != '\'' || quote == '"') {
+				current.WriteString(strings.Repeat(`\`, n))
+				i += n - 1
+				continue
+			}
+			current.WriteString(strings.Repeat(`\`, n/2))
+			if n%2 == 1 {
+				current.WriteRune(next)
+				i += n
+			} else {
+				i += n - 1
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// Positional returns the arguments that aren't flags: bare words before the "--" terminator,
+// and everything after it. The program name is not included.
+// Without flag definitions there is no telling a flag's value from a positional argument,
+// so pass flag values as "--name=value" if that matters.
+func (e Environment) Positional() []string {
+	var positional []string
+	args := e.args()
+	for i, arg := range args {
+		if arg == "--" {
+			return append(positional, args[i+1:]...)
+		}
+		if strings.HasPrefix(arg, "-") && arg != "-" {
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	return positional
+}
+
+// Arg returns the nth positional argument, counting from zero
+func (e Environment) Arg(n int) (string, bool) {
+	positional := e.Positional()
+	if n < 0 || n >= len(positional) {
+		return "", false
+	}
+	return positional[n], true
+}
+
+// RequireArgs checks that there are between min and max positional arguments.
+// A negative max means there is no upper bound.
+// When the count is out of range, it writes why to ErrorStream and returns a [UsageError].
+// [Command.Execute] doesn't write the error again, but still prints usage and exits with [ExitCodeMisuseOfBuiltIns].
+func (e Environment) RequireArgs(min, max int) error {
+	n := len(e.Positional())
+	var err error
+	switch {
+	case n < min:
+		err = fmt.Errorf("expected at least %d arguments but got %d", min, n)
+	case max >= 0 && n > max:
+		err = fmt.Errorf("expected at most %d arguments but got %d", max, n)
+	default:
+		return nil
+	}
+	err = UsageError{err}
+	fmt.Fprintln(e.ErrorStream, e.FormatError(err))
+	return reportedError{err}
+}
+
+// ReparseArgs replaces Arguments by splitting RawCommandLine with tokenizer,
+// for commands that need their own quoting rules, such as a shell's, rather than the operating system's.
+// It does nothing if RawCommandLine is empty.
+func (e *Environment) ReparseArgs(tokenizer func(string) []string) {
+	if e.RawCommandLine == "" {
+		return
+	}
+	e.Arguments = tokenizer(e.RawCommandLine)
+}
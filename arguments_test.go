@@ -0,0 +1,178 @@
+package flargs_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_ExpandArguments(t *testing.T) {
+
+	newEnv := func() *flargs.Environment {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Variables["HOME"] = "/home/robin"
+		env.Arguments = []string{"prog", "--out=$HOME/x", "${HOME}", "$UNSET", "--", "$HOME"}
+		return env
+	}
+
+	t.Run("everything", func(t *testing.T) {
+		env := newEnv()
+		env.ExpandAllArguments()
+		want := []string{"prog", "--out=/home/robin/x", "/home/robin", "", "--", "/home/robin"}
+		if !slices.Equal(env.Arguments, want) {
+			t.Errorf("got %q but wanted %q", env.Arguments, want)
+		}
+	})
+
+	t.Run("skip after terminator", func(t *testing.T) {
+		env := newEnv()
+		env.ExpandArguments()
+		want := []string{"prog", "--out=/home/robin/x", "/home/robin", "", "--", "$HOME"}
+		if !slices.Equal(env.Arguments, want) {
+			t.Errorf("got %q but wanted %q", env.Arguments, want)
+		}
+	})
+
+}
+
+func TestEnvironment_ExpandResponseFiles(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem.WriteFile("args.txt", []byte("-n\n--title \"two words\"\n'single quoted' @more.txt\n"), 0644)
+	env.Filesystem.WriteFile("more.txt", []byte("a.txt b.txt"), 0644)
+	env.Arguments = []string{"kat", "@args.txt", "c.txt", "--", "@args.txt"}
+
+	if err := env.ExpandResponseFiles(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"kat", "-n", "--title", "two words", "single quoted", "a.txt", "b.txt", "c.txt", "--", "@args.txt"}
+	if !slices.Equal(env.Arguments, want) {
+		t.Errorf("got %q but wanted %q", env.Arguments, want)
+	}
+
+}
+
+func TestEnvironment_ExpandResponseFiles_backslashes(t *testing.T) {
+
+	for contents, want := range map[string][]string{
+		`C:\src\main.c "C:\Program Files\app"`: {`C:\src\main.c`, `C:\Program Files\app`},
+		`say \"hi\"`:                           {"say", `"hi"`},
+		`"C:\out dir\\" next`:                  {`C:\out dir\`, "next"},
+		`a\\\"b`:                               {`a\"b`},
+		`"don\'t"`:                             {`don\'t`},
+		`'\n'`:                                 {`\n`},
+	} {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Filesystem.WriteFile("args.txt", []byte(contents), 0644)
+		env.Arguments = []string{"cc", "@args.txt"}
+		if err := env.ExpandResponseFiles(); err != nil {
+			t.Errorf("%s: %v", contents, err)
+			continue
+		}
+		if got := env.Arguments[1:]; !slices.Equal(got, want) {
+			t.Errorf("%s: got %q but wanted %q", contents, got, want)
+		}
+	}
+
+}
+
+func TestEnvironment_ExpandResponseFiles_depth(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem.WriteFile("loop.txt", []byte("@loop.txt"), 0644)
+	env.Arguments = []string{"kat", "@loop.txt"}
+
+	if err := env.ExpandResponseFiles(); err == nil {
+		t.Error("wanted an error for self-including response files")
+	}
+
+}
+
+func TestEnvironment_Positional(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Arguments = []string{"kat", "-n", "a.txt", "--verbose", "-", "b.txt", "--", "-c.txt"}
+
+	want := []string{"a.txt", "-", "b.txt", "-c.txt"}
+	if got := env.Positional(); !slices.Equal(got, want) {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if got, ok := env.Arg(1); !ok || got != "-" {
+		t.Errorf("got %q, %v but wanted -", got, ok)
+	}
+	if _, ok := env.Arg(4); ok {
+		t.Error("got an argument past the end")
+	}
+
+}
+
+func TestEnvironment_RequireArgs(t *testing.T) {
+
+	table := []struct {
+		name     string
+		args     []string
+		min, max int
+		wantErr  bool
+	}{
+		{"under", []string{"prog"}, 1, 2, true},
+		{"within", []string{"prog", "a"}, 1, 2, false},
+		{"at max", []string{"prog", "a", "b"}, 1, 2, false},
+		{"over", []string{"prog", "a", "b", "c"}, 1, 2, true},
+		{"unbounded", []string{"prog", "a", "b", "c"}, 1, -1, false},
+	}
+	for _, row := range table {
+		t.Run(row.name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			env.Arguments = row.args
+			err := env.RequireArgs(row.min, row.max)
+			if (err != nil) != row.wantErr {
+				t.Fatalf("got %v", err)
+			}
+			if code := flargs.ExitCodeOf(err); row.wantErr && code != flargs.ExitCodeMisuseOfBuiltIns {
+				t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeMisuseOfBuiltIns)
+			}
+			if row.wantErr && !strings.HasPrefix(err.Error(), "expected at") {
+				t.Errorf("got %q", err)
+			}
+			stderr := string(env.GetError())
+			if !row.wantErr && stderr != "" {
+				t.Errorf("got unexpected stderr %q", stderr)
+			}
+			if want := "error: " + fmt.Sprint(err) + "\n"; row.wantErr && stderr != want {
+				t.Errorf("got stderr %q but wanted %q", stderr, want)
+			}
+		})
+	}
+
+}
+
+func TestEnvironment_ReparseArgs(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Arguments = []string{"prog", "a", "b"}
+	env.ReparseArgs(strings.Fields)
+	if want := []string{"prog", "a", "b"}; !slices.Equal(env.Arguments, want) {
+		t.Errorf("got %q but wanted Arguments untouched with no RawCommandLine", env.Arguments)
+	}
+
+	env.RawCommandLine = `prog "C:\Program Files\app" /flag:x`
+	env.ReparseArgs(func(line string) []string {
+		var args []string
+		for i, part := range strings.Split(line, `"`) {
+			if i%2 == 1 {
+				args = append(args, part)
+			} else {
+				args = append(args, strings.Fields(part)...)
+			}
+		}
+		return args
+	})
+	want := []string{"prog", `C:\Program Files\app`, "/flag:x"}
+	if !slices.Equal(env.Arguments, want) {
+		t.Errorf("got %q but wanted %q", env.Arguments, want)
+	}
+
+}
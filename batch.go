@@ -0,0 +1,36 @@
+package flargs
+
+import "errors"
+
+// BatchPolicy decides what happens when one item in a batch fails
+type BatchPolicy uint8
+
+const (
+	BatchCollectErrors BatchPolicy = iota // keep going, and report every error at the end
+	BatchAbortOnError                     // stop at the first error
+)
+
+// BatchMode returns [BatchAbortOnError] if the "--fail-fast" flag is passed
+// or FLARGS_BATCH is "fail-fast". Otherwise it returns [BatchCollectErrors].
+func (e Environment) BatchMode() BatchPolicy {
+	if e.hasFlag("--fail-fast") || e.Variables["FLARGS_BATCH"] == "fail-fast" {
+		return BatchAbortOnError
+	}
+	return BatchCollectErrors
+}
+
+// RunBatch calls fn for each item.
+// Under [BatchAbortOnError] it returns the first error immediately.
+// Under [BatchCollectErrors] it processes every item and returns all errors joined by [errors.Join].
+func RunBatch[T any](items []T, policy BatchPolicy, fn func(T) error) error {
+	var errs []error
+	for _, item := range items {
+		if err := fn(item); err != nil {
+			if policy == BatchAbortOnError {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
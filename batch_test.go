@@ -0,0 +1,70 @@
+package flargs_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestRunBatch(t *testing.T) {
+
+	items := []int{1, 2, 3, 4}
+	failOnEven := func(seen *[]int) func(int) error {
+		return func(i int) error {
+			*seen = append(*seen, i)
+			if i%2 == 0 {
+				return fmt.Errorf("%d is even", i)
+			}
+			return nil
+		}
+	}
+
+	t.Run("abort on error", func(t *testing.T) {
+		var seen []int
+		err := flargs.RunBatch(items, flargs.BatchAbortOnError, failOnEven(&seen))
+		if err == nil || err.Error() != "2 is even" {
+			t.Errorf("got %v but wanted the first error", err)
+		}
+		if len(seen) != 2 {
+			t.Errorf("got %d items processed but wanted 2", len(seen))
+		}
+	})
+
+	t.Run("collect errors", func(t *testing.T) {
+		var seen []int
+		err := flargs.RunBatch(items, flargs.BatchCollectErrors, failOnEven(&seen))
+		if err == nil || err.Error() != "2 is even\n4 is even" {
+			t.Errorf("got %v but wanted both errors", err)
+		}
+		if len(seen) != 4 {
+			t.Errorf("got %d items processed but wanted 4", len(seen))
+		}
+	})
+
+	t.Run("no errors", func(t *testing.T) {
+		err := flargs.RunBatch(items, flargs.BatchCollectErrors, func(int) error { return nil })
+		if err != nil {
+			t.Errorf("got %v but wanted nil", err)
+		}
+	})
+
+}
+
+func TestEnvironment_BatchMode(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	if got := env.BatchMode(); got != flargs.BatchCollectErrors {
+		t.Errorf("got %d but wanted the default", got)
+	}
+	env.Variables["FLARGS_BATCH"] = "fail-fast"
+	if got := env.BatchMode(); got != flargs.BatchAbortOnError {
+		t.Errorf("got %d from the variable", got)
+	}
+	env = flargs.NewTestingEnvironment(nil)
+	env.Arguments = []string{"prog", "--fail-fast", "a.txt"}
+	if got := env.BatchMode(); got != flargs.BatchAbortOnError {
+		t.Errorf("got %d from the flag", got)
+	}
+
+}
@@ -0,0 +1,127 @@
+package flargs
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// a Clock tells time. Inject one into an [Environment] to make time-dependent commands testable.
+type Clock interface {
+	Now() time.Time
+	After(time.Duration) <-chan time.Time
+}
+
+// SystemClock is a [Clock] backed by the wall clock
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// SetClock installs c as the Environment's Clock,
+// and as the Clock of its Filesystem, if that is, or wraps, a [MemFS].
+func (e *Environment) SetClock(c Clock) {
+	e.Clock = c
+	if m, ok := asMemFS(e.Filesystem); ok {
+		m.Clock = c
+	}
+}
+
+// timestampLayout sorts lexically in time order and is safe in any filename
+const timestampLayout = "20060102T150405Z"
+
+// TimestampName builds a filename from the Clock's time in UTC, like "backup-20240102T150405Z.tar",
+// so with a [FixedClock] or [FakeClock] the name is the same on every run. The leading dot on ext is optional.
+func (e Environment) TimestampName(prefix, ext string) string {
+	name := e.Clock.Now().UTC().Format(timestampLayout)
+	if prefix != "" {
+		name = prefix + "-" + name
+	}
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return name + ext
+}
+
+// FixedClock is a [Clock] that is stopped at Time.
+// No time ever passes, so rather than block forever its After channels fire at once, with Time.
+// Under a FixedClock every timeout expires straight away; use a [FakeClock] to control when.
+type FixedClock struct {
+	Time time.Time
+}
+
+func (c FixedClock) Now() time.Time {
+	return c.Time
+}
+
+func (c FixedClock) After(_ time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Time
+	return ch
+}
+
+// FakeClock is a [Clock] that only moves when told to, with [FakeClock.Advance].
+// Its After channels fire once it has been advanced past their deadlines, so timeouts and retries can be driven from a test.
+// The zero value is stopped at the zero time. It is safe for concurrent use.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock returns a [FakeClock] stopped at start
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{c.now.Add(d), ch})
+	return ch
+}
+
+// Advance moves the clock forward by d, firing every After channel whose deadline has been reached
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// Waiters is how many After channels are yet to fire.
+// A test can poll it to know that the code under test is waiting before it calls Advance.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
@@ -0,0 +1,85 @@
+package flargs_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestFakeClock_Advance(t *testing.T) {
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := flargs.NewFakeClock(start)
+	soon := clock.After(time.Second)
+	later := clock.After(time.Minute)
+
+	clock.Advance(999 * time.Millisecond)
+	select {
+	case <-soon:
+		t.Fatal("After fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Millisecond)
+	select {
+	case got := <-soon:
+		if want := start.Add(time.Second); !got.Equal(want) {
+			t.Errorf("got %s but wanted %s", got, want)
+		}
+	default:
+		t.Fatal("After did not fire at its deadline")
+	}
+	if n := clock.Waiters(); n != 1 {
+		t.Errorf("got %d waiters but wanted 1", n)
+	}
+	select {
+	case <-later:
+		t.Error("a later After fired early")
+	default:
+	}
+
+}
+
+func TestFakeClock_drivesWithTimeout(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	clock := flargs.NewFakeClock(time.Unix(0, 0))
+	env.SetClock(clock)
+	release := make(chan struct{})
+	defer close(release)
+	slow := flargs.CommandFunc(func(*flargs.Environment) error {
+		<-release
+		return nil
+	})
+	slow.Environment = env
+
+	done := make(chan flargs.ExitCode)
+	go func() {
+		done <- flargs.WithTimeout(slow, time.Hour, nil).Execute(nil)
+	}()
+	for clock.Waiters() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	clock.Advance(time.Hour)
+	if code := <-done; code != flargs.ExitCodeTimeout {
+		t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeTimeout)
+	}
+
+}
+
+func TestEnvironment_TimestampName(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	clock := flargs.NewFakeClock(time.Date(2024, 1, 2, 15, 4, 5, 0, time.FixedZone("EST", -5*3600)))
+	env.SetClock(clock)
+
+	if got, want := env.TimestampName("backup", ".tar"), "backup-20240102T200405Z.tar"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	clock.Advance(time.Minute)
+	if got, want := env.TimestampName("", "log"), "20240102T200505Z.log"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package flargs
+
+import (
+	"os"
+	"strings"
+)
+
+// rawCommandLine rebuilds a command line from [os.Args], which is all other platforms keep.
+// Arguments are single-quoted where a shell would need it.
+func rawCommandLine() string {
+	quoted := make([]string, len(os.Args))
+	for i, arg := range os.Args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package flargs
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// rawCommandLine is the command line exactly as Windows passed it to the process
+func rawCommandLine() string {
+	p := syscall.GetCommandLine()
+	n := 0
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; ptr = unsafe.Add(ptr, 2) {
+		n++
+	}
+	return syscall.UTF16ToString(unsafe.Slice(p, n))
+}
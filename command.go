@@ -1,6 +1,8 @@
 package flargs
 
 import (
+	"errors"
+	"fmt"
 	"io"
 )
 
@@ -45,6 +47,116 @@ func (k Command) Run() error {
 	return k.Flarger.Run(k.Environment)
 }
 
+// Execute parses, loads and runs the [Command], returning an [ExitCode].
+// Any error is written to the ErrorStream, using the Environment's ErrorFormatter, except a bare ExitCode,
+// which means the command has already said what went wrong, or one a helper such as [Environment.TransformLines] has already reported.
+// A [UsageError] is followed by usage, when the Flarger has a WriteUsage(io.Writer) method, as a [FlargSet] or [Router] does.
+// Before returning, buffered streams are flushed and closable ones closed, so no output is lost.
+// A broken pipe, as when output is piped to head(1) and head has seen enough, counts as success.
+// Hooks registered with [Environment.OnBeforeRun] and [Environment.OnAfterRun] are called around it all.
+func (k Command) Execute(args []string) ExitCode {
+	for _, hook := range k.beforeRun {
+		hook(k.Environment)
+	}
+	err := k.ParseAndLoad(args)
+	if err == nil {
+		err = k.Run()
+	}
+	if isBrokenPipe(err) {
+		err = nil
+	}
+	if _, bare := err.(ExitCode); err != nil && !bare && !errors.As(err, new(reportedError)) {
+		fmt.Fprintln(k.ErrorStream, k.FormatError(err))
+	}
+	if u, ok := k.Flarger.(usager); ok && errors.As(err, new(UsageError)) {
+		u.WriteUsage(k.ErrorStream)
+	}
+	for _, hook := range k.afterRun {
+		hook(k.Environment, int(ExitCodeOf(err)))
+	}
+	if closeErr := k.closeStreams(); err == nil && closeErr != nil && !isBrokenPipe(closeErr) {
+		err = closeErr
+		fmt.Fprintln(k.ErrorStream, k.FormatError(err))
+	}
+	//	ErrorStream goes last, since everything before may need to write to it
+	if closeErr := closeStream(k.ErrorStream); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	return ExitCodeOf(err)
+}
+
+// isBrokenPipe reports whether err is from writing to a pipe whose reader has gone away
+func isBrokenPipe(err error) bool {
+	return isEPIPE(err) || errors.Is(err, io.ErrClosedPipe)
+}
+
+// CommandFunc adapts a plain function into a [Command].
+// Parse and Load are no-ops. Attach an [Environment] before running it.
+func CommandFunc(fn func(e *Environment) error) Command {
+	return Command{Flarger: &funcFlarger{run: fn}}
+}
+
+type funcFlarger struct {
+	StateMachine
+	run func(*Environment) error
+}
+
+func (f *funcFlarger) Run(env *Environment) error {
+	f.Phase = Running
+	return f.run(env)
+}
+
+// NoOp is a [Command] that does nothing, successfully
+func NoOp() Command {
+	return CommandFunc(func(*Environment) error {
+		return nil
+	})
+}
+
+// EchoInput is a [Command] that copies InputStream to OutputStream
+func EchoInput() Command {
+	return CommandFunc(func(e *Environment) error {
+		_, err := io.Copy(e.OutputStream, e.InputStream)
+		return err
+	})
+}
+
+// RunWith executes c in one call, for tests and for embedding.
+// It runs against a fresh [NewDeterministicEnvironment], with stdin as InputStream, vars added to Variables,
+// and Arguments of "flargs" followed by args. It returns what was written to the output and error streams, and the exit code, as an int to hand to [os.Exit].
+func RunWith(c Command, args []string, stdin string, vars map[string]string) (stdout, stderr string, code int) {
+	env := NewDeterministicEnvironment(0)
+	env.InputStream.Write([]byte(stdin))
+	for k, v := range vars {
+		env.Variables[k] = v
+	}
+	env.Arguments = append([]string{"flargs"}, args...)
+	c.Environment = env
+	code = int(c.Execute(args))
+	return env.OutputString(), string(env.GetError()), code
+}
+
+// RunAll executes commands one after another, each against its own [Environment.Fork] of e, and with no arguments.
+// After each one, its output and errors are appended to e's streams, so they land in the order the commands were given.
+// The exit codes are returned in the same order. If a command's output can't be appended, that is reported
+// to e's ErrorStream, and a command that had succeeded gets [ExitCodeGenericError].
+func RunAll(e *Environment, cmds ...Command) (codes []int) {
+	codes = make([]int, len(cmds))
+	for i, c := range cmds {
+		child, merge := e.Fork()
+		c.Environment = child
+		code := c.Execute(nil)
+		if err := merge(); err != nil {
+			fmt.Fprintln(e.ErrorStream, e.FormatError(err))
+			if code == ExitCodeSuccess {
+				code = ExitCodeGenericError
+			}
+		}
+		codes[i] = int(code)
+	}
+	return codes
+}
+
 // Pipe pipes one Command to another
 func Pipe(f1 Command, f2 Command) (int64, error) {
 	f1.Run()
@@ -0,0 +1,405 @@
+package flargs_test
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+	"github.com/sean9999/go-flargs/rot13"
+)
+
+func TestCommandFunc(t *testing.T) {
+
+	table := []struct {
+		name     string
+		err      error
+		wantCode flargs.ExitCode
+		wantErr  string
+	}{
+		{"nil", nil, flargs.ExitCodeSuccess, ""},
+		{"plain error", errors.New("boom"), flargs.ExitCodeGenericError, "error: boom\n"},
+		{"flarg error", flargs.NewFlargError(flargs.ExitCodeCommandNotFound, errors.New("no such thing")), flargs.ExitCodeCommandNotFound, "error: flargs error: no such thing\n"},
+		{"bare exit code", flargs.ExitCodeTimeout, flargs.ExitCodeTimeout, ""},
+	}
+
+	for _, row := range table {
+		t.Run(row.name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			cmd := flargs.CommandFunc(func(_ *flargs.Environment) error {
+				return row.err
+			})
+			cmd.Environment = env
+			got := cmd.Execute(nil)
+			if got != row.wantCode {
+				t.Errorf("got exit code %d but wanted %d", got, row.wantCode)
+			}
+			if gotErr := string(env.GetError()); gotErr != row.wantErr {
+				t.Errorf("got stderr %q but wanted %q", gotErr, row.wantErr)
+			}
+		})
+	}
+
+}
+
+// closeRecorder is a stream that remembers being closed
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Write(p []byte) (int, error) {
+	if c.closed {
+		return 0, os.ErrClosed
+	}
+	return c.Buffer.Write(p)
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+// failingCloser is a stream that can't be closed
+type failingCloser struct {
+	bytes.Buffer
+}
+
+func (failingCloser) Close() error {
+	return errors.New("disk full on close")
+}
+
+func TestCommand_Execute_closeError(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	stderr := new(closeRecorder)
+	env.ErrorStream = stderr
+	env.OutputStream = new(failingCloser)
+
+	cmd := flargs.CommandFunc(func(e *flargs.Environment) error {
+		return nil
+	})
+	cmd.Environment = env
+
+	if code := cmd.Execute(nil); code != flargs.ExitCodeGenericError {
+		t.Errorf("got exit code %d", code)
+	}
+	if got, want := stderr.String(), "error: disk full on close\n"; got != want {
+		t.Errorf("got stderr %q but wanted %q", got, want)
+	}
+	if !stderr.closed {
+		t.Error("closable ErrorStream was not closed")
+	}
+
+}
+
+func TestCommand_Execute_flushes(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	stderr := new(closeRecorder)
+	env.ErrorStream = stderr
+	env.BufferOutput()
+
+	cmd := flargs.CommandFunc(func(e *flargs.Environment) error {
+		fmt.Fprint(e.OutputStream, "partial output")
+		return errors.New("then it broke")
+	})
+	cmd.Environment = env
+
+	if code := cmd.Execute(nil); code != flargs.ExitCodeGenericError {
+		t.Errorf("got exit code %d", code)
+	}
+	if got := string(env.GetOutput()); got != "partial output" {
+		t.Errorf("got %q but wanted the partial output", got)
+	}
+	if !stderr.closed {
+		t.Error("closable ErrorStream was not closed")
+	}
+	if got := stderr.String(); got != "error: then it broke\n" {
+		t.Errorf("got stderr %q", got)
+	}
+
+}
+
+func TestCommand_Execute_errorFormatter(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.ErrorFormatter = func(err error) string {
+		return "✗ " + strings.ToUpper(err.Error())
+	}
+	cmd := flargs.CommandFunc(func(_ *flargs.Environment) error {
+		return errors.New("disk full")
+	})
+	cmd.Environment = env
+	cmd.Execute(nil)
+	if got, want := string(env.GetError()), "✗ DISK FULL\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+// copier is a Flarger that wants exactly two arguments, and can describe itself with its FlargSet
+type copier struct {
+	flargs.StateMachine
+	*flargs.FlargSet
+	err error
+}
+
+func (c *copier) Parse(args []string) error {
+	return c.FlargSet.Parse(args)
+}
+
+func (c *copier) Run(env *flargs.Environment) error {
+	return c.err
+}
+
+func TestCommand_Execute_usageError(t *testing.T) {
+
+	table := []struct {
+		name      string
+		err       error
+		wantCode  flargs.ExitCode
+		wantUsage bool
+	}{
+		{"usage error", flargs.UsageError{Err: errors.New("want SRC and DST")}, flargs.ExitCodeMisuseOfBuiltIns, true},
+		{"wrapped usage error", fmt.Errorf("cp: %w", flargs.UsageError{Err: errors.New("want SRC and DST")}), flargs.ExitCodeMisuseOfBuiltIns, true},
+		{"plain error", errors.New("disk full"), flargs.ExitCodeGenericError, false},
+	}
+	for _, row := range table {
+		t.Run(row.name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			flags := flargs.NewFlargSet("cp", flag.ContinueOnError)
+			flags.Bool("r", false, "copy directories recursively")
+			cmd := flargs.Command{Flarger: &copier{FlargSet: flags, err: row.err}, Environment: env}
+			if code := cmd.Execute(nil); code != row.wantCode {
+				t.Errorf("got exit code %d but wanted %d", code, row.wantCode)
+			}
+			stderr := string(env.GetError())
+			if !strings.HasPrefix(stderr, "error: "+row.err.Error()+"\n") {
+				t.Errorf("got stderr %q", stderr)
+			}
+			if gotUsage := strings.Contains(stderr, "Usage of cp:\n  -r\tcopy directories recursively\n"); gotUsage != row.wantUsage {
+				t.Errorf("got stderr %q, which should show usage: %v", stderr, row.wantUsage)
+			}
+		})
+	}
+
+}
+
+func TestCommand_Execute_unknownFlag(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	flags := flargs.NewFlargSet("cp", flag.ContinueOnError)
+	flags.Bool("r", false, "copy directories recursively")
+	var leaked bytes.Buffer
+	flags.SetOutput(&leaked)
+	cmd := flargs.Command{Flarger: &copier{FlargSet: flags}, Environment: env}
+
+	if code := cmd.Execute([]string{"-bogus"}); code != flargs.ExitCodeMisuseOfBuiltIns {
+		t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeMisuseOfBuiltIns)
+	}
+	want := "error: flag provided but not defined: -bogus\nUsage of cp:\n  -r\tcopy directories recursively\n"
+	if got := string(env.GetError()); got != want {
+		t.Errorf("got stderr %q but wanted %q", got, want)
+	}
+	if leaked.Len() != 0 {
+		t.Errorf("got %q written to the FlargSet's own output", leaked.String())
+	}
+
+}
+
+func TestCommand_Execute_requireArgs(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Arguments = []string{"cp"}
+	cmd := flargs.CommandFunc(func(e *flargs.Environment) error {
+		return e.RequireArgs(2, 2)
+	})
+	cmd.Environment = env
+	if code := cmd.Execute(nil); code != flargs.ExitCodeMisuseOfBuiltIns {
+		t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeMisuseOfBuiltIns)
+	}
+	if got, want := string(env.GetError()), "error: expected at least 2 arguments but got 0\n"; got != want {
+		t.Errorf("got stderr %q but wanted %q", got, want)
+	}
+
+}
+
+func TestNoOp(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	cmd := flargs.NoOp()
+	cmd.Environment = env
+	if code := cmd.Execute(nil); code != flargs.ExitCodeSuccess {
+		t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeSuccess)
+	}
+	if out, errs := env.GetOutput(), env.GetError(); len(out)+len(errs) != 0 {
+		t.Errorf("got output %q and errors %q but wanted nothing", out, errs)
+	}
+
+}
+
+func TestEchoInput(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fmt.Fprint(env.InputStream, "round and round\n")
+	cmd := flargs.EchoInput()
+	cmd.Environment = env
+	if code := cmd.Execute(nil); code != flargs.ExitCodeSuccess {
+		t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeSuccess)
+	}
+	if got, want := env.OutputString(), "round and round\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestRunAll(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	say := func(word string, err error) flargs.Command {
+		return flargs.CommandFunc(func(e *flargs.Environment) error {
+			fmt.Fprintln(e.OutputStream, word)
+			return err
+		})
+	}
+
+	codes := flargs.RunAll(env,
+		say("one", nil),
+		say("two", flargs.ExitCodeTimeout),
+		say("three", errors.New("three failed")),
+	)
+
+	want := []int{int(flargs.ExitCodeSuccess), int(flargs.ExitCodeTimeout), int(flargs.ExitCodeGenericError)}
+	if !slices.Equal(codes, want) {
+		t.Errorf("got codes %v but wanted %v", codes, want)
+	}
+	if got, want := env.OutputString(), "one\ntwo\nthree\n"; got != want {
+		t.Errorf("got output %q but wanted %q", got, want)
+	}
+	if got, want := string(env.GetError()), "error: three failed\n"; got != want {
+		t.Errorf("got errors %q but wanted %q", got, want)
+	}
+
+}
+
+func TestRunAll_sharedVariables(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	set := func(key string) flargs.Command {
+		return flargs.CommandFunc(func(e *flargs.Environment) error {
+			e.SetVar(key, "done")
+			return nil
+		})
+	}
+	flargs.RunAll(env, set("A"), set("B"), set("C"))
+	for _, key := range []string{"A", "B", "C"} {
+		if got := env.Variables[key]; got != "done" {
+			t.Errorf("%s: got %q but wanted %q", key, got, "done")
+		}
+	}
+
+}
+
+func TestRunAll_mergeFails(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.OutputStream = flargs.CallbackWriter(func([]byte) error {
+		return errors.New("disk full")
+	}).(io.ReadWriter)
+	codes := flargs.RunAll(env, flargs.CommandFunc(func(e *flargs.Environment) error {
+		fmt.Fprintln(e.OutputStream, "lost")
+		return nil
+	}))
+	if want := []int{int(flargs.ExitCodeGenericError)}; !slices.Equal(codes, want) {
+		t.Errorf("got codes %v but wanted %v", codes, want)
+	}
+	if got, want := string(env.GetError()), "error: disk full\n"; got != want {
+		t.Errorf("got errors %q but wanted %q", got, want)
+	}
+
+}
+
+func TestRunWith(t *testing.T) {
+
+	t.Run("rot13", func(t *testing.T) {
+		stdout, stderr, code := flargs.RunWith(flargs.NewCommand(new(rot13.RotKonf), nil), nil, "Hello, World", nil)
+		if code != int(flargs.ExitCodeSuccess) || stderr != "" {
+			t.Fatalf("got exit code %d and stderr %q", code, stderr)
+		}
+		if want := "Uryyb, Jbeyq"; stdout != want {
+			t.Errorf("got %q but wanted %q", stdout, want)
+		}
+	})
+
+	t.Run("args and vars", func(t *testing.T) {
+		greet := flargs.CommandFunc(func(e *flargs.Environment) error {
+			whom, ok := e.Arg(0)
+			if !ok {
+				return errors.New("whom should I greet?")
+			}
+			fmt.Fprintf(e.OutputStream, "%s, %s\n", e.Variables["GREETING"], whom)
+			return nil
+		})
+		stdout, _, code := flargs.RunWith(greet, []string{"robin"}, "", map[string]string{"GREETING": "hi"})
+		if code != int(flargs.ExitCodeSuccess) || stdout != "hi, robin\n" {
+			t.Errorf("got %q with exit code %d", stdout, code)
+		}
+		_, stderr, code := flargs.RunWith(greet, nil, "", nil)
+		if code != int(flargs.ExitCodeGenericError) || stderr != "error: whom should I greet?\n" {
+			t.Errorf("got stderr %q with exit code %d", stderr, code)
+		}
+	})
+
+}
+
+// headWriter accepts n bytes, then fails as a pipe does once head(1) has exited
+type headWriter struct {
+	bytes.Buffer
+	n int
+}
+
+func (h *headWriter) Write(p []byte) (int, error) {
+	if h.Len()+len(p) > h.n {
+		return 0, &os.PathError{Op: "write", Path: "|1", Err: errPipe}
+	}
+	return h.Buffer.Write(p)
+}
+
+func TestCommand_Execute_brokenPipe(t *testing.T) {
+
+	for name, pipeErr := range map[string]func(error) error{
+		"EPIPE":         func(err error) error { return err },
+		"ErrClosedPipe": func(error) error { return fmt.Errorf("writing: %w", io.ErrClosedPipe) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			out := &headWriter{n: 12}
+			env.OutputStream = out
+			cmd := flargs.CommandFunc(func(e *flargs.Environment) error {
+				for i := 0; ; i++ {
+					if _, err := fmt.Fprintf(e.OutputStream, "line %d\n", i); err != nil {
+						return pipeErr(err)
+					}
+				}
+			})
+			cmd.Environment = env
+			if code := cmd.Execute(nil); code != flargs.ExitCodeSuccess {
+				t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeSuccess)
+			}
+			if got := string(env.GetError()); got != "" {
+				t.Errorf("got stderr %q but wanted nothing", got)
+			}
+			if got, want := out.String(), "line 0\n"; got != want {
+				t.Errorf("got %q but wanted %q", got, want)
+			}
+		})
+	}
+
+}
@@ -0,0 +1,208 @@
+package flargs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// LoadConfig merges a structured config file from the Filesystem into Variables.
+// The format follows the extension: ".json", or ".toml" for a subset of TOML covering
+// tables, dotted keys, strings, numbers, booleans and single-line arrays.
+// Nested keys are flattened into variable names, so server.port becomes SERVER_PORT,
+// and array elements are numbered from 0, as in HOSTS_0. Variables that are already set are left alone.
+func (e *Environment) LoadConfig(name string) error {
+	data, err := e.Filesystem.ReadFile(e.ResolvePath(name))
+	if err != nil {
+		return err
+	}
+	var tree map[string]any
+	switch ext := strings.ToLower(path.Ext(name)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		err = dec.Decode(&tree)
+	case ".toml":
+		tree, err = parseTOML(data)
+	default:
+		return fmt.Errorf("%s: unsupported config format %q", name, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	flat := map[string]string{}
+	flattenConfig("", tree, flat)
+	for k, v := range flat {
+		if _, set := e.Variables[k]; !set {
+			e.Variables[k] = v
+			e.tagVar(k, OriginFile)
+		}
+	}
+	return nil
+}
+
+// configKey turns a config key into a variable name
+func configKey(prefix, key string) string {
+	key = strings.ToUpper(strings.NewReplacer(".", "_", "-", "_", " ", "_").Replace(key))
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+func flattenConfig(prefix string, v any, into map[string]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			flattenConfig(configKey(prefix, k), child, into)
+		}
+	case []any:
+		for i, child := range v {
+			flattenConfig(configKey(prefix, strconv.Itoa(i)), child, into)
+		}
+	case nil:
+		into[prefix] = ""
+	default:
+		into[prefix] = fmt.Sprint(v)
+	}
+}
+
+// parseTOML parses the subset of TOML described at [Environment.LoadConfig]
+func parseTOML(data []byte) (map[string]any, error) {
+	root := map[string]any{}
+	table := root
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: unsupported table header %q", n, line)
+			}
+			var err error
+			table, err = tomlTable(root, strings.Split(strings.TrimSpace(line[1:len(line)-1]), "."))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			continue
+		}
+		key, raw, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		value, err := parseTOMLValue(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		parts := strings.Split(strings.TrimSpace(key), ".")
+		parent, err := tomlTable(table, parts[:len(parts)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		parent[strings.Trim(strings.TrimSpace(parts[len(parts)-1]), `"`)] = value
+	}
+	return root, scanner.Err()
+}
+
+// tomlTable finds or creates the table at keys beneath t
+func tomlTable(t map[string]any, keys []string) (map[string]any, error) {
+	for _, k := range keys {
+		k = strings.Trim(strings.TrimSpace(k), `"`)
+		child, exists := t[k]
+		if !exists {
+			child = map[string]any{}
+			t[k] = child
+		}
+		sub, isTable := child.(map[string]any)
+		if !isTable {
+			return nil, fmt.Errorf("%s is not a table", k)
+		}
+		t = sub
+	}
+	return t, nil
+}
+
+// outsideTOMLStrings calls fn with each rune of s that isn't inside a string, until fn returns false
+func outsideTOMLStrings(s string, fn func(i int, r rune) bool) {
+	var quote rune
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		default:
+			if !fn(i, r) {
+				return
+			}
+		}
+	}
+}
+
+// stripTOMLComment drops a # comment, unless the # is inside a string
+func stripTOMLComment(line string) string {
+	end := len(line)
+	outsideTOMLStrings(line, func(i int, r rune) bool {
+		if r == '#' {
+			end = i
+			return false
+		}
+		return true
+	})
+	return line[:end]
+}
+
+// splitTOMLArray splits the inside of a single-line array at commas that aren't inside strings
+func splitTOMLArray(s string) []string {
+	var parts []string
+	start := 0
+	outsideTOMLStrings(s, func(i int, r rune) bool {
+		if r == ',' {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+		return true
+	})
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+func parseTOMLValue(s string) (any, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") && len(s) > 1:
+		return s[1 : len(s)-1], nil
+	case strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]"):
+		var values []any
+		for _, part := range splitTOMLArray(s[1 : len(s)-1]) {
+			v, err := parseTOMLValue(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case s == "true" || s == "false":
+		return s == "true", nil
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil {
+		return strings.ReplaceAll(s, "_", ""), nil
+	}
+	return nil, fmt.Errorf("unsupported value %q", s)
+}
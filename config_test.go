@@ -0,0 +1,81 @@
+package flargs_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_LoadConfig(t *testing.T) {
+
+	t.Run("json", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Filesystem.WriteFile("/etc/app.json", []byte(`{
+			"server": {"port": 8080, "host": "0.0.0.0", "tls": false},
+			"log-level": "debug",
+			"peers": ["a", "b"]
+		}`), 0644)
+		if err := env.LoadConfig("/etc/app.json"); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{
+			"SERVER_PORT": "8080",
+			"SERVER_HOST": "0.0.0.0",
+			"SERVER_TLS":  "false",
+			"LOG_LEVEL":   "debug",
+			"PEERS_0":     "a",
+			"PEERS_1":     "b",
+		}
+		if got := env.AddedVars(); !maps.Equal(got, want) {
+			t.Errorf("got %v but wanted %v", got, want)
+		}
+	})
+
+	t.Run("collision", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Variables["SERVER_PORT"] = "9090"
+		env.Filesystem.WriteFile("app.json", []byte(`{"server": {"port": 8080}}`), 0644)
+		if err := env.LoadConfig("app.json"); err != nil {
+			t.Fatal(err)
+		}
+		if got := env.Variables["SERVER_PORT"]; got != "9090" {
+			t.Errorf("got %q but wanted the existing %q kept", got, "9090")
+		}
+	})
+
+	t.Run("toml", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Filesystem.WriteFile("app.toml", []byte(`
+# top level
+name = "flargs # not a comment"
+
+[server]
+port = 8_080 # a comment
+tls.enabled = true
+hosts = ["a", 'b,c']
+`), 0644)
+		if err := env.LoadConfig("app.toml"); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{
+			"NAME":               "flargs # not a comment",
+			"SERVER_PORT":        "8080",
+			"SERVER_TLS_ENABLED": "true",
+			"SERVER_HOSTS_0":     "a",
+			"SERVER_HOSTS_1":     "b,c",
+		}
+		if got := env.AddedVars(); !maps.Equal(got, want) {
+			t.Errorf("got %v but wanted %v", got, want)
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Filesystem.WriteFile("app.yaml", []byte("a: b"), 0644)
+		if err := env.LoadConfig("app.yaml"); err == nil {
+			t.Error("wanted an error for an unsupported format")
+		}
+	})
+
+}
@@ -0,0 +1,45 @@
+package flargs
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// SecretPatterns are the key patterns [Environment.MarshalDebug] redacts
+var SecretPatterns = []string{"*TOKEN*", "*SECRET*", "*PASSWORD*", "*PASSWD*", "*CREDENTIAL*", "*_KEY"}
+
+const redacted = "****"
+
+// RedactedVars returns a copy of Variables where the value of any key matching one of patterns is replaced by "****".
+// Patterns are globs as understood by [path.Match], compared without regard to case.
+func (e Environment) RedactedVars(patterns ...string) map[string]string {
+	out := make(map[string]string, len(e.Variables))
+	for key, value := range e.Variables {
+		out[key] = value
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(strings.ToUpper(pattern), strings.ToUpper(key)); matched {
+				out[key] = redacted
+				break
+			}
+		}
+	}
+	return out
+}
+
+// MarshalDebug dumps the Environment as JSON for bug reports.
+// Variables that look like secrets, per [SecretPatterns], are redacted.
+func (e Environment) MarshalDebug() ([]byte, error) {
+	return json.MarshalIndent(struct {
+		Variables  map[string]string `json:"variables"`
+		Arguments  []string          `json:"arguments"`
+		WorkingDir string            `json:"workingDir"`
+		Filesystem string            `json:"filesystem"`
+	}{
+		Variables:  e.RedactedVars(SecretPatterns...),
+		Arguments:  e.Arguments,
+		WorkingDir: e.WorkingDir,
+		Filesystem: fmt.Sprintf("%T", e.Filesystem),
+	}, "", "  ")
+}
@@ -0,0 +1,54 @@
+package flargs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_RedactedVars(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Variables = map[string]string{
+		"GITHUB_TOKEN": "ghp_abc",
+		"db_password":  "hunter2",
+		"HOME":         "/home/robin",
+	}
+
+	got := env.RedactedVars("*_TOKEN", "*PASSWORD*")
+	want := map[string]string{
+		"GITHUB_TOKEN": "****",
+		"db_password":  "****",
+		"HOME":         "/home/robin",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: got %q but wanted %q", k, got[k], v)
+		}
+	}
+	if env.Variables["GITHUB_TOKEN"] != "ghp_abc" {
+		t.Error("RedactedVars modified Variables")
+	}
+
+}
+
+func TestEnvironment_MarshalDebug(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Variables["AWS_SECRET_ACCESS_KEY"] = "wJalrXUtnFEMI"
+	env.Variables["EDITOR"] = "vim"
+
+	data, err := env.MarshalDebug()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := string(data)
+	if strings.Contains(dump, "wJalrXUtnFEMI") {
+		t.Errorf("secret leaked into %s", dump)
+	}
+	if !strings.Contains(dump, `"EDITOR": "vim"`) {
+		t.Errorf("ordinary variable missing from %s", dump)
+	}
+
+}
@@ -0,0 +1,30 @@
+package flargs
+
+import "fmt"
+
+// DryRun reports whether side effects should only be described, because of a --dry-run argument,
+// or FLARGS_DRY_RUN being true, as understood by [Environment.GetBool].
+// [Environment.WriteFileAtomic], [Environment.CreateTemp] and [Environment.Remove] honour it.
+func (e Environment) DryRun() bool {
+	if e.hasFlag("--dry-run") {
+		return true
+	}
+	on, _ := e.GetBool("FLARGS_DRY_RUN")
+	return on
+}
+
+// dryRun writes what would have been done to ErrorStream
+func (e Environment) dryRun(format string, a ...any) {
+	fmt.Fprintf(e.ErrorStream, "dry run: would "+format+"\n", a...)
+}
+
+// Remove removes the file name from the Filesystem. A relative name is resolved against WorkingDir.
+// In a [Environment.DryRun], it only says what it would remove.
+func (e Environment) Remove(name string) error {
+	target := e.ResolvePath(name)
+	if e.DryRun() {
+		e.dryRun("remove %s", target)
+		return nil
+	}
+	return e.Filesystem.Remove(target)
+}
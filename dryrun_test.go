@@ -0,0 +1,69 @@
+package flargs_test
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_DryRun(t *testing.T) {
+
+	for name, setup := range map[string]func(*flargs.Environment){
+		"flag":     func(e *flargs.Environment) { e.Arguments = []string{"prog", "--dry-run"} },
+		"variable": func(e *flargs.Environment) { e.Variables["FLARGS_DRY_RUN"] = "yes" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := flargs.NewDeterministicEnvironment(1)
+			env.Filesystem.WriteFile("/old.txt", []byte("keep me"), 0644)
+			setup(env)
+			if !env.DryRun() {
+				t.Fatal("wanted a dry run")
+			}
+
+			if err := env.WriteFileAtomic("notes.txt", []byte("hello")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := env.Filesystem.Stat("/notes.txt"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("got %v but wanted the write not to happen", err)
+			}
+			if err := env.Remove("/old.txt"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := env.Filesystem.Stat("/old.txt"); err != nil {
+				t.Errorf("got %v but wanted the file left alone", err)
+			}
+			f, err := env.CreateTemp("", "scratch-*")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := f.Write([]byte("discarded")); err != nil {
+				t.Errorf("got %v but wanted a writable file", err)
+			}
+			if entries, _ := env.Filesystem.ReadDir("/tmp"); len(entries) != 0 {
+				t.Errorf("got %d temporary files but wanted none", len(entries))
+			}
+			if _, err := env.Filesystem.Stat(f.Name()); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("got %v for %s but wanted it not to exist", err, f.Name())
+			}
+
+			lines := strings.Split(strings.TrimSpace(string(env.GetError())), "\n")
+			if len(lines) != 3 {
+				t.Fatalf("got %q but wanted three lines", lines)
+			}
+			for i, want := range []string{"dry run: would write 5 bytes to /notes.txt", "dry run: would remove /old.txt", "dry run: would create /tmp/scratch-"} {
+				if !strings.HasPrefix(lines[i], want) {
+					t.Errorf("got %q but wanted %q", lines[i], want)
+				}
+			}
+		})
+	}
+
+	env := flargs.NewTestingEnvironment(nil)
+	if env.DryRun() {
+		t.Error("wanted no dry run by default")
+	}
+
+}
@@ -0,0 +1,73 @@
+package flargs
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// EnvCommand returns a ready-made [Command] that describes its [Environment].
+// It prints sorted Variables, Arguments, and the type of Filesystem.
+// Variables that look like secrets, per [SecretPatterns], are redacted.
+// Pass "--json" for machine-readable output.
+func EnvCommand(env *Environment) Command {
+	return NewCommand(new(envKonf), env)
+}
+
+type envKonf struct {
+	asJSON bool
+	StateMachine
+}
+
+type envReport struct {
+	Variables  map[string]string `json:"variables"`
+	Arguments  []string          `json:"arguments"`
+	Filesystem struct {
+		Type string `json:"type"`
+	} `json:"filesystem"`
+}
+
+func (k *envKonf) Parse(args []string) error {
+	k.Phase = Parsing
+	//	Parse has no Environment to write to, so a bad flag is only returned, for Execute to report
+	fset := NewFlargSet("env", flag.ContinueOnError)
+	fset.SetOutput(io.Discard)
+	fset.BoolVar(&k.asJSON, "json", false, "output json")
+	err := fset.Parse(args)
+	k.RemainingArgs = fset.Args()
+	return err
+}
+
+func (k *envKonf) Run(env *Environment) error {
+	k.Phase = Running
+	report := envReport{
+		Variables: env.RedactedVars(SecretPatterns...),
+		Arguments: env.Arguments,
+	}
+	report.Filesystem.Type = fmt.Sprintf("%T", env.Filesystem)
+
+	if k.asJSON {
+		enc := json.NewEncoder(env.OutputStream)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+
+	keys := make([]string, 0, len(report.Variables))
+	for key := range report.Variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fmt.Fprintln(env.OutputStream, "Variables:")
+	for _, key := range keys {
+		fmt.Fprintf(env.OutputStream, "  %s=%s\n", key, report.Variables[key])
+	}
+	fmt.Fprintln(env.OutputStream, "Arguments:")
+	for _, arg := range report.Arguments {
+		fmt.Fprintf(env.OutputStream, "  %s\n", arg)
+	}
+	fmt.Fprintln(env.OutputStream, "Filesystem:")
+	_, err := fmt.Fprintf(env.OutputStream, "  type: %s\n", report.Filesystem.Type)
+	return err
+}
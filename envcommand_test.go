@@ -0,0 +1,78 @@
+package flargs_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+	realfs "github.com/sean9999/go-real-fs"
+)
+
+func TestEnvCommand(t *testing.T) {
+
+	newEnv := func() *flargs.Environment {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Filesystem = realfs.NewTestFs()
+		env.Variables["ZED"] = "last"
+		env.Variables["ALPHA"] = "first"
+		env.Variables["API_TOKEN"] = "hunter2"
+		env.Arguments = []string{"prog", "env"}
+		return env
+	}
+
+	t.Run("text", func(t *testing.T) {
+		env := newEnv()
+		if code := flargs.EnvCommand(env).Execute(nil); code != flargs.ExitCodeSuccess {
+			t.Fatalf("got exit code %d: %s", code, env.GetError())
+		}
+		want := `Variables:
+  ALPHA=first
+  API_TOKEN=****
+  FLARGS_EXE_ENVIRONMENT=testing
+  ZED=last
+Arguments:
+  prog
+  env
+Filesystem:
+  type: realfs.TestFS
+`
+		got := string(env.GetOutput())
+		if got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		env := newEnv()
+		if code := flargs.EnvCommand(env).Execute([]string{"--json"}); code != flargs.ExitCodeSuccess {
+			t.Fatalf("got exit code %d: %s", code, env.GetError())
+		}
+		var got struct {
+			Variables  map[string]string `json:"variables"`
+			Arguments  []string          `json:"arguments"`
+			Filesystem struct {
+				Type string `json:"type"`
+			} `json:"filesystem"`
+		}
+		if err := json.Unmarshal(env.GetOutput(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Variables["API_TOKEN"] != "****" {
+			t.Errorf("got API_TOKEN=%q but wanted it redacted", got.Variables["API_TOKEN"])
+		}
+		if got.Variables["ALPHA"] != "first" || len(got.Arguments) != 2 || got.Filesystem.Type != "realfs.TestFS" {
+			t.Errorf("got unexpected report %+v", got)
+		}
+	})
+
+	t.Run("bad flag", func(t *testing.T) {
+		env := newEnv()
+		if code := flargs.EnvCommand(env).Execute([]string{"-bogus"}); code != flargs.ExitCodeMisuseOfBuiltIns {
+			t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeMisuseOfBuiltIns)
+		}
+		if got, want := string(env.GetError()), "error: flag provided but not defined: -bogus\n"; got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+	})
+
+}
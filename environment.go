@@ -2,11 +2,15 @@ package flargs
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"io/fs"
+	"maps"
 	"math/rand"
 	"os"
+	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	rfs "github.com/sean9999/go-real-fs"
@@ -24,43 +28,140 @@ type Environment struct {
 	Filesystem   rfs.WritableFs
 	Variables    map[string]string
 	Arguments    []string
+	Clock        Clock
+	WorkingDir   string
+	StartTime    time.Time
+	Context      context.Context
+	// RawCommandLine is the command line before it was split into Arguments.
+	// On Windows it is exactly what the process was given. Elsewhere it is rebuilt from [os.Args].
+	RawCommandLine string
+	// ErrorFormatter renders the errors [Command.Execute] writes to ErrorStream.
+	// When nil, an error is written as "error: " followed by its message.
+	ErrorFormatter func(err error) string
+	// Exec builds the subprocesses [Environment.ExecCommand] prepares. When nil, it is [exec.Command].
+	// Tests can swap in a fake that returns canned output and exit codes.
+	Exec func(name string, args ...string) *exec.Cmd
+	// DefaultFileMode is the mode of files the Environment's helpers create. Zero means 0644. See [Environment.FileMode].
+	DefaultFileMode fs.FileMode
+	metrics         *Metrics
+	sinks           *sinkSet
+	varSources      []VarSource
+	baseVars        map[string]string
+	artifacts       *artifacts
+	lines           *lineReader
+	fallbackRand    *fallbackSource
+	umask           fs.FileMode
+	beforeRun       []func(*Environment)
+	afterRun        []func(*Environment, int)
+	origins         map[string]Origin
+	fds             map[int]io.Reader
+	restoreError    func()
 }
 
-func (e Environment) GetOutput() []byte {
-	buf, _ := io.ReadAll(e.OutputStream)
+// lazyMu guards the shared state an Environment creates on first use when no constructor set it up
+var lazyMu sync.Mutex
+
+// snapshotter is a stream that can report its unread content without consuming it
+type snapshotter interface {
+	snapshot() ([]byte, bool)
+}
+
+// readStream returns the unread content of rw.
+// A [bytes.Buffer] (or a stream wrapping one) is left as it was, so repeated calls agree.
+// Any other stream is drained.
+func readStream(rw io.ReadWriter) []byte {
+	switch s := rw.(type) {
+	case *bytes.Buffer:
+		return bytes.Clone(s.Bytes())
+	case snapshotter:
+		if buf, ok := s.snapshot(); ok {
+			return buf
+		}
+	}
+	buf, _ := io.ReadAll(rw)
 	return buf
 }
 
+// GetOutput returns what has been written to OutputStream.
+// For buffer-backed streams, it does not consume the content.
+func (e Environment) GetOutput() []byte {
+	return readStream(e.OutputStream)
+}
+
+// OutputString is [Environment.GetOutput] as a string
+func (e Environment) OutputString() string {
+	return string(e.GetOutput())
+}
+
+// OutputTrimmed is [Environment.OutputString] with one trailing newline removed,
+// so output can be compared against "hello" rather than "hello\n".
+func (e Environment) OutputTrimmed() string {
+	return strings.TrimSuffix(e.OutputString(), "\n")
+}
+
+// GetError returns what has been written to ErrorStream.
+// For buffer-backed streams, it does not consume the content.
 func (e Environment) GetError() []byte {
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(e.ErrorStream)
-	return buf.Bytes()
+	return readStream(e.ErrorStream)
 }
 
+// GetInput returns the unread content of InputStream.
+// For buffer-backed streams, it does not consume the content.
 func (e Environment) GetInput() []byte {
-	buf := new(bytes.Buffer)
-	buf.ReadFrom(e.InputStream)
-	return buf.Bytes()
+	return readStream(e.InputStream)
 }
 
-// NewCLIEnvironment produces an Environment suitable for a CLI.
-// It's a helper function with sane defaults.
-func NewCLIEnvironment(baseDir string) *Environment {
-	envAsMap := func(envs []string) map[string]string {
-		m := make(map[string]string)
-		i := 0
-		for _, s := range envs {
-			i = strings.IndexByte(s, '=')
-			m[s[0:i]] = s[i+1:]
+// truncate empties rw if it is a [bytes.Buffer], or anything else that can Reset
+func truncate(rw io.ReadWriter) {
+	if r, ok := rw.(interface{ Reset() }); ok {
+		r.Reset()
+	}
+}
+
+// TruncateOutput discards what has been written to OutputStream, so one Environment can serve several phases of a test.
+// It is a no-op unless OutputStream is a [bytes.Buffer], or otherwise has a Reset method.
+func (e Environment) TruncateOutput() {
+	truncate(e.OutputStream)
+}
+
+// TruncateError is [Environment.TruncateOutput] for ErrorStream
+func (e Environment) TruncateError() {
+	truncate(e.ErrorStream)
+}
+
+// TruncateInput is [Environment.TruncateOutput] for InputStream
+func (e Environment) TruncateInput() {
+	truncate(e.InputStream)
+}
+
+// envAsMap turns KEY=VALUE pairs, as from [os.Environ], into a map.
+// Entries without a "=" or with an empty key, like the "=C:" entries on Windows, are skipped.
+func envAsMap(envs []string) map[string]string {
+	m := make(map[string]string, len(envs))
+	for _, s := range envs {
+		key, value, found := strings.Cut(s, "=")
+		if !found || key == "" {
+			continue
 		}
-		return m
+		m[key] = value
 	}
+	return m
+}
 
+// NewCLIEnvironment produces an Environment suitable for a CLI.
+// It's a helper function with sane defaults.
+func NewCLIEnvironment(baseDir string) *Environment {
 	//	import parent env vars
 	vars := envAsMap(os.Environ())
+	origins := make(map[string]Origin, len(vars))
+	for k := range vars {
+		origins[k] = OriginInherited
+	}
 	vars["FLARGS_EXE_ENVIRONMENT"] = "cli"
+	origins["FLARGS_EXE_ENVIRONMENT"] = OriginSet
 
-	realFs := rfs.NewWritable()
+	realFs := osFS{rfs.NewWritable()}
+	cwd, _ := os.Getwd()
 
 	env := Environment{
 		InputStream:  os.Stdin,
@@ -70,7 +171,18 @@ func NewCLIEnvironment(baseDir string) *Environment {
 		Filesystem:   realFs,
 		Variables:    vars,
 		Arguments:    os.Args,
+		Clock:        SystemClock{},
+		WorkingDir:   cwd,
+		StartTime:    time.Now(),
+		Context:      context.Background(),
+		metrics:      newMetrics(),
+		artifacts:    newArtifacts(),
+		lines:        new(lineReader),
+		fallbackRand: new(fallbackSource),
 	}
+	env.RawCommandLine = rawCommandLine()
+	env.origins = origins
+	env.baseVars = maps.Clone(env.Variables)
 	return &env
 }
 
@@ -78,18 +190,28 @@ func NewCLIEnvironment(baseDir string) *Environment {
 // Pass in a "randomnessProvider" that offers a level of determinism that works for you.
 // For good ole fashioned regular randomness, pass in [rand.Reader]
 // If your program doesn't use randomness, just pass in nil.
+// The Filesystem is an empty [MemFS].
 func NewTestingEnvironment(randomnessProvider rand.Source) *Environment {
 	env := Environment{
 		InputStream:  new(bytes.Buffer),
 		OutputStream: new(bytes.Buffer),
 		ErrorStream:  new(bytes.Buffer),
 		Randomness:   randomnessProvider,
-		Filesystem:   rfs.NewWritable(),
+		Filesystem:   NewMemFS(),
 		Variables: map[string]string{
 			"FLARGS_EXE_ENVIRONMENT": "testing",
 		},
-		Arguments: []string{},
+		Arguments:    []string{},
+		Clock:        SystemClock{},
+		WorkingDir:   "/",
+		StartTime:    time.Now(),
+		Context:      context.Background(),
+		metrics:      newMetrics(),
+		artifacts:    newArtifacts(),
+		lines:        new(lineReader),
+		fallbackRand: new(fallbackSource),
 	}
+	env.baseVars = maps.Clone(env.Variables)
 	return &env
 }
 
@@ -136,6 +258,65 @@ func NewNullEnvironment() *Environment {
 		Filesystem:   NullDevice{},
 		Variables:    map[string]string{},
 		Arguments:    []string{},
+		Clock:        SystemClock{},
+		WorkingDir:   "/",
+		Context:      context.Background(),
+		metrics:      newMetrics(),
+		artifacts:    newArtifacts(),
+		lines:        new(lineReader),
+		fallbackRand: new(fallbackSource),
 	}
+	e.baseVars = maps.Clone(e.Variables)
 	return &e
 }
+
+// NewDeterministicEnvironment produces a testing [Environment] where nothing varies from run to run.
+// Randomness is seeded with seed, the Clock is a [FixedClock] stopped at the Unix epoch,
+// the Filesystem is an empty [MemFS] and the streams are empty buffers.
+func NewDeterministicEnvironment(seed int64) *Environment {
+	env := NewTestingEnvironment(rand.NewSource(seed))
+	env.SetClock(FixedClock{time.Unix(0, 0).UTC()})
+	env.StartTime = env.Clock.Now()
+	return env
+}
+
+// NewConcurrentTestingEnvironment is [NewTestingEnvironment] with every stream wrapped in a [SyncWriter],
+// for commands that write from several goroutines at once.
+func NewConcurrentTestingEnvironment(randomnessProvider rand.Source) *Environment {
+	env := NewTestingEnvironment(randomnessProvider)
+	env.InputStream = &syncStream{w: env.InputStream}
+	env.OutputStream = &syncStream{w: env.OutputStream}
+	env.ErrorStream = &syncStream{w: env.ErrorStream}
+	return env
+}
+
+// AddedVars returns the Variables that were set or changed since the Environment was constructed,
+// leaving out everything inherited.
+func (e Environment) AddedVars() map[string]string {
+	added := map[string]string{}
+	for k, v := range e.Variables {
+		if base, inherited := e.baseVars[k]; !inherited || base != v {
+			added[k] = v
+		}
+	}
+	return added
+}
+
+// WithContext returns a shallow copy of the Environment bound to ctx.
+// Helpers that take a context use this one when they are passed nil.
+func (e *Environment) WithContext(ctx context.Context) *Environment {
+	c := *e
+	c.Context = ctx
+	return &c
+}
+
+// ctxOr returns ctx, falling back to the Environment's Context, and then to [context.Background]
+func (e Environment) ctxOr(ctx context.Context) context.Context {
+	if ctx != nil {
+		return ctx
+	}
+	if e.Context != nil {
+		return e.Context
+	}
+	return context.Background()
+}
@@ -0,0 +1,27 @@
+package flargs
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestEnvAsMap(t *testing.T) {
+
+	envs := []string{
+		"HOME=/home/robin",
+		"MALFORMED",
+		"=C:=C:\\Users",
+		"EMPTY=",
+		"EQUATION=a=b",
+	}
+	want := map[string]string{
+		"HOME":     "/home/robin",
+		"EMPTY":    "",
+		"EQUATION": "a=b",
+	}
+	got := envAsMap(envs)
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v but wanted %v", got, want)
+	}
+
+}
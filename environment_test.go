@@ -0,0 +1,162 @@
+package flargs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestNewDeterministicEnvironment(t *testing.T) {
+
+	sample := func(env *flargs.Environment) []byte {
+		env.Filesystem.WriteFile("stamp.txt", nil, 0644)
+		info, _ := env.Filesystem.Stat("stamp.txt")
+		fmt.Fprintln(env.OutputStream, env.UUID())
+		fmt.Fprintln(env.OutputStream, env.Clock.Now().Format("2006-01-02T15:04:05Z07:00"))
+		fmt.Fprintln(env.OutputStream, info.ModTime().Unix())
+		return env.GetOutput()
+	}
+
+	a := sample(flargs.NewDeterministicEnvironment(99))
+	b := sample(flargs.NewDeterministicEnvironment(99))
+	if !bytes.Equal(a, b) {
+		t.Errorf("got %q and %q but wanted them equal", a, b)
+	}
+	c := sample(flargs.NewDeterministicEnvironment(100))
+	if bytes.Equal(a, c) {
+		t.Error("different seeds produced identical output")
+	}
+
+}
+
+func TestEnvironment_WithContext(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	parent := flargs.NewTestingEnvironment(nil)
+	env := parent.WithContext(ctx)
+	if parent.Context == ctx {
+		t.Fatal("WithContext modified the original Environment")
+	}
+	env.Filesystem.WriteFile("app.log", nil, 0644)
+	cancel()
+
+	t.Run("tail", func(t *testing.T) {
+		done := make(chan error)
+		go func() {
+			done <- env.Tail(nil, "app.log", io.Discard)
+		}()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Error(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Tail ignored the carried context")
+		}
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		if _, err := env.Copy(nil); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v but wanted context.Canceled", err)
+		}
+		if _, err := env.Copy(context.Background()); err != nil {
+			t.Errorf("got %v but wanted an explicit context to win over the carried one", err)
+		}
+	})
+
+	t.Run("prompt", func(t *testing.T) {
+		r, w := io.Pipe()
+		defer w.Close()
+		env.InputStream = struct {
+			io.Reader
+			io.Writer
+		}{r, io.Discard}
+		_, err := env.PromptWithTimeout("name?", "", time.Hour)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v but wanted context.Canceled", err)
+		}
+	})
+
+}
+
+func TestEnvironment_GetOutput_repeatable(t *testing.T) {
+
+	for name, env := range map[string]*flargs.Environment{
+		"testing":    flargs.NewTestingEnvironment(nil),
+		"concurrent": flargs.NewConcurrentTestingEnvironment(nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			fmt.Fprint(env.OutputStream, "out")
+			fmt.Fprint(env.ErrorStream, "err")
+			for range 2 {
+				if got := string(env.GetOutput()); got != "out" {
+					t.Errorf("got output %q but wanted %q", got, "out")
+				}
+				if got := string(env.GetError()); got != "err" {
+					t.Errorf("got error %q but wanted %q", got, "err")
+				}
+			}
+		})
+	}
+
+}
+
+func TestEnvironment_AddedVars(t *testing.T) {
+
+	env := flargs.NewCLIEnvironment("/")
+	env.Variables["FLARGS_TEST_ADDED"] = "yes"
+	env.Variables["FLARGS_EXE_ENVIRONMENT"] = "changed"
+
+	got := env.AddedVars()
+	want := map[string]string{
+		"FLARGS_TEST_ADDED":      "yes",
+		"FLARGS_EXE_ENVIRONMENT": "changed",
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v but wanted %v", got, want)
+	}
+
+}
+
+func TestEnvironment_TruncateOutput(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fmt.Fprint(env.OutputStream, "phase one")
+	fmt.Fprint(env.ErrorStream, "warning")
+	env.TruncateOutput()
+	if got := env.GetOutput(); len(got) != 0 {
+		t.Errorf("got %q after truncating", got)
+	}
+	if got := string(env.GetError()); got != "warning" {
+		t.Errorf("got %q but wanted ErrorStream untouched", got)
+	}
+	fmt.Fprint(env.OutputStream, "phase two")
+	if got := string(env.GetOutput()); got != "phase two" {
+		t.Errorf("got %q but wanted %q", got, "phase two")
+	}
+
+	null := flargs.NewNullEnvironment()
+	null.TruncateOutput()
+
+}
+
+func TestEnvironment_OutputTrimmed(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fmt.Fprintln(env.OutputStream, "hello")
+	fmt.Fprintln(env.OutputStream)
+	if got, want := env.OutputString(), "hello\n\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if got, want := env.OutputTrimmed(), "hello\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
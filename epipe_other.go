@@ -0,0 +1,13 @@
+//go:build !plan9
+
+package flargs
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isEPIPE reports whether err is the EPIPE a write to a pipe with no reader fails with
+func isEPIPE(err error) bool {
+	return errors.Is(err, syscall.EPIPE)
+}
@@ -0,0 +1,8 @@
+//go:build !plan9
+
+package flargs_test
+
+import "syscall"
+
+// errPipe is what a write to a pipe with no reader fails with
+var errPipe error = syscall.EPIPE
@@ -0,0 +1,8 @@
+//go:build plan9
+
+package flargs
+
+// isEPIPE is always false, since Plan 9 has no EPIPE. A write to a hung-up pipe there fails with [io.ErrClosedPipe] or a plain error.
+func isEPIPE(_ error) bool {
+	return false
+}
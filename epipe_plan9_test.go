@@ -0,0 +1,8 @@
+//go:build plan9
+
+package flargs_test
+
+import "io"
+
+// errPipe stands in for EPIPE, which Plan 9 does not have
+var errPipe = io.ErrClosedPipe
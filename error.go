@@ -1,6 +1,10 @@
 package flargs
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"io"
+)
 
 type ExitCode uint8
 
@@ -22,6 +26,9 @@ const (
 	ExitCodeFatalErrorSignal9
 )
 
+// ExitCodeTimeout is what GNU timeout(1) exits with when a command runs too long
+const ExitCodeTimeout ExitCode = 124
+
 func (ec ExitCode) Error() string {
 	return fmt.Sprintf("exit code: %d", ec)
 }
@@ -39,3 +46,98 @@ func NewFlargError(exitcode ExitCode, underlying error) *FlargError {
 	fe := &FlargError{exitcode, underlying}
 	return fe
 }
+
+// A UsageError means the command was invoked wrongly, rather than failed while running.
+// It exits with [ExitCodeMisuseOfBuiltIns], which is 2, and [Command.Execute] follows it with usage, if there is any.
+type UsageError struct {
+	Err error
+}
+
+func (u UsageError) Error() string {
+	return u.Err.Error()
+}
+
+func (u UsageError) Unwrap() error {
+	return u.Err
+}
+
+// reportedError is an error that has already been written to ErrorStream, so [Command.Execute] doesn't write it again.
+// It still decides the exit code, as the error it wraps would.
+type reportedError struct {
+	error
+}
+
+func (r reportedError) Unwrap() error {
+	return r.error
+}
+
+// usager is anything that can describe how it is invoked, as [FlargSet] and [Router] can
+type usager interface {
+	WriteUsage(w io.Writer)
+}
+
+// ExitCodeOf maps an error to an [ExitCode].
+// nil is success. A [UsageError] is misuse. A [FlargError] or a bare [ExitCode] carries its own code.
+// Anything else is a generic error.
+func ExitCodeOf(err error) ExitCode {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	if errors.As(err, new(UsageError)) {
+		return ExitCodeMisuseOfBuiltIns
+	}
+	var fe *FlargError
+	if errors.As(err, &fe) {
+		return fe.ExitCode
+	}
+	var ec ExitCode
+	if errors.As(err, &ec) {
+		return ec
+	}
+	return ExitCodeGenericError
+}
+
+// Errorf is like [fmt.Errorf], but the resulting error remembers the [Environment] it came from.
+// Formatted with %+v, it also reports the Arguments, execution context and WorkingDir.
+func (e Environment) Errorf(format string, a ...any) error {
+	return &envError{
+		err:       fmt.Errorf(format, a...),
+		arguments: append([]string(nil), e.Arguments...),
+		context:   e.Variables["FLARGS_EXE_ENVIRONMENT"],
+		dir:       e.WorkingDir,
+	}
+}
+
+type envError struct {
+	err       error
+	arguments []string
+	context   string
+	dir       string
+}
+
+func (ee *envError) Error() string {
+	return ee.err.Error()
+}
+
+func (ee *envError) Unwrap() error {
+	return ee.err
+}
+
+func (ee *envError) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		fmt.Fprintf(s, "%s\n\targuments: %q\n\tcontext: %s\n\tworking dir: %s", ee.err, ee.arguments, ee.context, ee.dir)
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", ee.err.Error())
+	default:
+		io.WriteString(s, ee.err.Error())
+	}
+}
+
+// FormatError renders err with the ErrorFormatter, or as "error: " followed by its message if there is none
+func (e Environment) FormatError(err error) string {
+	if e.ErrorFormatter != nil {
+		return e.ErrorFormatter(err)
+	}
+	return "error: " + err.Error()
+}
@@ -0,0 +1,33 @@
+package flargs_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_Errorf(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Arguments = []string{"kat", "-n", "base.txt"}
+	err := env.Errorf("could not open %s: %w", "base.txt", fs.ErrNotExist)
+
+	if got, want := err.Error(), "could not open base.txt: file does not exist"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Error("wrapped error was lost")
+	}
+
+	verbose := fmt.Sprintf("%+v", err)
+	for _, want := range []string{`"kat" "-n" "base.txt"`, "context: testing", "working dir: /"} {
+		if !strings.Contains(verbose, want) {
+			t.Errorf("%q does not contain %q", verbose, want)
+		}
+	}
+
+}
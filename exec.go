@@ -0,0 +1,75 @@
+package flargs
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ExecCommand prepares a subprocess that runs in the Environment.
+// It is bound to the Environment's Context, runs in WorkingDir, sees Variables as its environment,
+// and reads and writes the Environment's streams.
+// When Exec is set, it builds the command instead, and any of those fields it already filled in are left alone.
+// Such a command isn't bound to the Context.
+func (e Environment) ExecCommand(name string, args ...string) *exec.Cmd {
+	if e.Exec == nil {
+		cmd := exec.CommandContext(e.ctxOr(nil), name, args...)
+		cmd.Dir = e.WorkingDir
+		cmd.Env = e.ChildEnviron(nil)
+		cmd.Stdin = e.InputStream
+		cmd.Stdout = e.OutputStream
+		cmd.Stderr = e.ErrorStream
+		return cmd
+	}
+	cmd := e.Exec(name, args...)
+	if cmd.Dir == "" {
+		cmd.Dir = e.WorkingDir
+	}
+	if cmd.Env == nil {
+		cmd.Env = e.ChildEnviron(nil)
+	}
+	if cmd.Stdin == nil {
+		cmd.Stdin = e.InputStream
+	}
+	if cmd.Stdout == nil {
+		cmd.Stdout = e.OutputStream
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = e.ErrorStream
+	}
+	return cmd
+}
+
+// ExecutablePath returns the path of the running program, for re-executing it or naming it in help text.
+// A CLI Environment asks [os.Executable]. Any other derives it from Arguments[0], so tests get the same answer on every machine:
+// a path is resolved against WorkingDir, but a bare name, which a shell would have looked up on PATH, is returned as it is.
+func (e Environment) ExecutablePath() (string, error) {
+	if e.Variables["FLARGS_EXE_ENVIRONMENT"] == "cli" {
+		return os.Executable()
+	}
+	if len(e.Arguments) == 0 || e.Arguments[0] == "" {
+		return "", errors.New("executable path: no program name in Arguments")
+	}
+	if !strings.ContainsAny(e.Arguments[0], `/\`) {
+		return e.Arguments[0], nil
+	}
+	return e.ResolvePath(e.Arguments[0]), nil
+}
+
+// Page shows content through the PAGER, like "less -R", when OutputStream is a terminal.
+// Otherwise, or when PAGER is unset, or under testing, content is written straight to OutputStream.
+func (e Environment) Page(content []byte) error {
+	argv, err := splitArgs(e.Variables["PAGER"])
+	if err != nil {
+		return err
+	}
+	if e.isTesting() || len(argv) == 0 || !e.IsTerminal() {
+		_, err := e.OutputStream.Write(content)
+		return err
+	}
+	cmd := e.ExecCommand(argv[0], argv[1:]...)
+	cmd.Stdin = bytes.NewReader(content)
+	return cmd.Run()
+}
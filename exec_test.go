@@ -0,0 +1,127 @@
+package flargs_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_Page(t *testing.T) {
+
+	for name, pager := range map[string]string{
+		"no pager":   "",
+		"under test": "less -R",
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			env.Variables["PAGER"] = pager
+			if err := env.Page([]byte("a long story\n")); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := env.OutputString(), "a long story\n"; got != want {
+				t.Errorf("got %q but wanted %q", got, want)
+			}
+		})
+	}
+
+}
+
+func TestEnvironment_ExecCommand(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.WorkingDir = t.TempDir()
+	env.Variables["GREETING"] = "hello"
+	cmd := env.ExecCommand("sh", "-c", `echo "$GREETING from $(pwd)"`)
+	if err := cmd.Run(); err != nil {
+		t.Skipf("no usable sh: %v", err)
+	}
+	if got, want := env.OutputTrimmed(), "hello from "+env.WorkingDir; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestEnvironment_ExecutablePath(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.WorkingDir = "/home/robin"
+	for arg0, want := range map[string]string{
+		"./bin/kat":    "/home/robin/bin/kat",
+		"/usr/bin/kat": "/usr/bin/kat",
+		"bin/kat":      "/home/robin/bin/kat",
+		"kat":          "kat",
+	} {
+		env.Arguments = []string{arg0, "--help"}
+		got, err := env.ExecutablePath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: got %q but wanted %q", arg0, got, want)
+		}
+	}
+
+	env.Arguments = nil
+	if _, err := env.ExecutablePath(); err == nil {
+		t.Error("wanted an error without a program name")
+	}
+
+	cli := flargs.NewCLIEnvironment("/")
+	got, err := cli.ExecutablePath()
+	if want, wantErr := os.Executable(); got != want || err != wantErr {
+		t.Errorf("got %q, %v but wanted %q, %v", got, err, want, wantErr)
+	}
+
+}
+
+// TestHelperProcess isn't a real test. It is the fake subprocess [fakeExec] runs:
+// it writes its arguments to stdout and exits with FAKE_EXIT_CODE.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+	args := os.Args
+	for len(args) > 0 && args[0] != "--" {
+		args = args[1:]
+	}
+	fmt.Println(args[1:])
+	code, _ := strconv.Atoi(os.Getenv("FAKE_EXIT_CODE"))
+	os.Exit(code)
+}
+
+// fakeExec runs the test binary as [TestHelperProcess] in place of name
+func fakeExec(code int) func(name string, args ...string) *exec.Cmd {
+	return func(name string, args ...string) *exec.Cmd {
+		cmd := exec.Command(os.Args[0], append([]string{"-test.run=TestHelperProcess", "--", name}, args...)...)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", "FAKE_EXIT_CODE=" + strconv.Itoa(code)}
+		return cmd
+	}
+}
+
+func TestEnvironment_Exec(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Exec = fakeExec(128)
+	gitStatus := flargs.CommandFunc(func(env *flargs.Environment) error {
+		err := env.ExecCommand("git", "status").Run()
+		if exitErr := new(exec.ExitError); errors.As(err, &exitErr) {
+			return flargs.ExitCode(exitErr.ExitCode())
+		}
+		return err
+	})
+	gitStatus.Environment = env
+	code := gitStatus.Execute(nil)
+
+	if code != 128 {
+		t.Errorf("got exit code %d but wanted %d", code, 128)
+	}
+	if got, want := env.OutputTrimmed(), "[git status]"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
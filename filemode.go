@@ -0,0 +1,82 @@
+package flargs
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	rfs "github.com/sean9999/go-real-fs"
+)
+
+// defaultFileMode is the mode of new files when DefaultFileMode is unset
+const defaultFileMode fs.FileMode = 0644
+
+// FileMode is the mode helpers such as [Environment.WriteFileAtomic] give new files:
+// DefaultFileMode, or 0644 if that is unset, with the bits in the [Environment.Umask] cleared.
+func (e Environment) FileMode() fs.FileMode {
+	mode := e.DefaultFileMode
+	if mode == 0 {
+		mode = defaultFileMode
+	}
+	return mode.Perm() &^ e.umask
+}
+
+// Umask sets permission bits to clear from new files, as umask(2) does for a process.
+// It does not change the process's own umask, which the operating system may apply on top.
+func (e *Environment) Umask(mask fs.FileMode) {
+	e.umask = mask.Perm()
+}
+
+// renamer is a Filesystem that can move files, as [MemFS] can
+type renamer interface {
+	Rename(oldname, newname string) error
+}
+
+// osFS is the real filesystem of a CLI Environment, with the Rename that [rfs.NewWritable] lacks
+type osFS struct {
+	rfs.WritableFs
+}
+
+// Rename moves oldname to newname with [os.Rename], resolving them as the rest of the real filesystem does
+func (o osFS) Rename(oldname, newname string) error {
+	from, err := filepath.Abs(filepath.FromSlash(oldname))
+	if err != nil {
+		return err
+	}
+	to, err := filepath.Abs(filepath.FromSlash(newname))
+	if err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+// WriteFileAtomic writes data to name with [Environment.FileMode], so that readers see either the old
+// contents or the new, never a partial write. Data goes to a temporary file beside name, which is then renamed over it.
+// A Filesystem that can't rename gets a plain WriteFile.
+// In a [Environment.DryRun], it only says what it would write.
+func (e Environment) WriteFileAtomic(name string, data []byte) error {
+	target := e.ResolvePath(name)
+	if e.DryRun() {
+		e.dryRun("write %d bytes to %s", len(data), target)
+		return nil
+	}
+	r, canRename := e.Filesystem.(renamer)
+	if !canRename {
+		return e.Filesystem.WriteFile(target, data, e.FileMode())
+	}
+	f, tmp, err := e.createTemp(path.Dir(target), "."+path.Base(target)+".*.tmp", e.FileMode())
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	err = errors.Join(err, f.Close())
+	if err == nil {
+		err = r.Rename(tmp, target)
+	}
+	if err != nil {
+		e.Filesystem.Remove(tmp)
+	}
+	return err
+}
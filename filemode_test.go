@@ -0,0 +1,103 @@
+package flargs_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_WriteFileAtomic(t *testing.T) {
+
+	env := flargs.NewDeterministicEnvironment(1)
+	env.Filesystem.WriteFile("/etc/app.conf", []byte("old"), 0644)
+	env.Umask(0027)
+	env.DefaultFileMode = 0666
+
+	if err := env.WriteFileAtomic("/etc/app.conf", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := env.Filesystem.ReadFile("/etc/app.conf")
+	if string(data) != "new" {
+		t.Errorf("got %q but wanted %q", data, "new")
+	}
+	info, err := env.Filesystem.Stat("/etc/app.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode().Perm(), fs.FileMode(0640); got != want {
+		t.Errorf("got mode %s but wanted %s", got, want)
+	}
+	entries, _ := env.ReadDir("/etc")
+	if len(entries) != 1 {
+		t.Errorf("got %d entries in /etc but wanted the temporary file gone", len(entries))
+	}
+
+}
+
+func TestEnvironment_WriteFileAtomic_realFilesystem(t *testing.T) {
+
+	env := flargs.NewCLIEnvironment("/")
+	env.WorkingDir = filepath.ToSlash(t.TempDir())
+	if err := env.WriteFileAtomic("app.conf", []byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.WriteFileAtomic("app.conf", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(env.WorkingDir, "app.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("got %q but wanted %q", data, "new")
+	}
+	entries, _ := os.ReadDir(env.WorkingDir)
+	if len(entries) != 1 {
+		t.Errorf("got %d entries but wanted the temporary file renamed away", len(entries))
+	}
+
+}
+
+func TestEnvironment_FileMode_helpers(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Umask(0077)
+	env.Metrics().Count("runs", 1)
+	if err := env.Metrics().Flush("/metrics.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.SinkOutputTo("/out.log"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"/metrics.json", "/out.log"} {
+		info, err := env.Filesystem.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := info.Mode().Perm(), fs.FileMode(0600); got != want {
+			t.Errorf("%s: got mode %s but wanted %s", name, got, want)
+		}
+	}
+
+}
+
+func TestEnvironment_FileMode(t *testing.T) {
+
+	env := flargs.NewDeterministicEnvironment(1)
+	if got, want := env.FileMode(), fs.FileMode(0644); got != want {
+		t.Errorf("got %s but wanted %s", got, want)
+	}
+	env.Umask(0077)
+	f, err := env.CreateTemp("", "scratch-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, _ := f.Stat()
+	if got, want := info.Mode().Perm(), fs.FileMode(0600); got != want {
+		t.Errorf("got temp file mode %s but wanted %s", got, want)
+	}
+
+}
@@ -0,0 +1,137 @@
+package flargs
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// FlargSet is a [flag.FlagSet] with extra validation.
+// Warnings and errors go to its Output, so point that at an ErrorStream with SetOutput.
+type FlargSet struct {
+	*flag.FlagSet
+	deprecated map[string]string
+	exclusive  [][]string
+	enable     byte
+	disable    byte
+}
+
+// NewFlargSet creates an empty [FlargSet], as [flag.NewFlagSet] does
+func NewFlargSet(name string, errorHandling flag.ErrorHandling) *FlargSet {
+	return &FlargSet{
+		FlagSet:    flag.NewFlagSet(name, errorHandling),
+		deprecated: map[string]string{},
+	}
+}
+
+// Deprecate marks the flag called name as deprecated.
+// Using it still works, but Parse writes a warning including message to Output, once.
+func (fs *FlargSet) Deprecate(name, message string) {
+	fs.deprecated[name] = message
+}
+
+// MutuallyExclusive declares that at most one of the named flags may be set
+func (fs *FlargSet) MutuallyExclusive(names ...string) {
+	fs.exclusive = append(fs.exclusive, names)
+}
+
+// SetPrefixes sets prefixes that turn boolean flags on and off, find and tar style.
+// After SetPrefixes('+', '-'), "+x" sets -x to true and "-x" sets it to false.
+// Flags that aren't boolean are unaffected. A zero byte leaves that prefix unset.
+func (fs *FlargSet) SetPrefixes(enable, disable byte) {
+	fs.enable = enable
+	fs.disable = disable
+}
+
+// WriteUsage writes the usage message, as [flag.FlagSet.Usage] would, to w rather than to Output
+func (fs *FlargSet) WriteUsage(w io.Writer) {
+	prev := fs.Output()
+	fs.SetOutput(w)
+	defer fs.SetOutput(prev)
+	fs.Usage()
+}
+
+// isBoolFlag reports whether name is a boolean flag in the set
+func (fs *FlargSet) isBoolFlag(name string) bool {
+	f := fs.Lookup(name)
+	if f == nil {
+		return false
+	}
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// rewritePrefixes turns prefixed boolean flags into the -name=true and -name=false that [flag] understands.
+// Like [flag], it stops at "--" or the first argument that isn't a flag.
+func (fs *FlargSet) rewritePrefixes(args []string) []string {
+	if fs.enable == 0 && fs.disable == 0 {
+		return args
+	}
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if len(arg) > 1 && fs.isBoolFlag(arg[1:]) {
+			switch arg[0] {
+			case fs.enable:
+				out = append(out, "-"+arg[1:]+"=true")
+				continue
+			case fs.disable:
+				out = append(out, "-"+arg[1:]+"=false")
+				continue
+			}
+		}
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return append(out, args[i:]...)
+		}
+		out = append(out, arg)
+		name := strings.TrimLeft(arg, "-")
+		if name != "" && !strings.Contains(name, "=") && fs.Lookup(name) != nil && !fs.isBoolFlag(name) {
+			//	the next argument is this flag's value
+			if i+1 < len(args) {
+				i++
+				out = append(out, args[i])
+			}
+		}
+	}
+	return out
+}
+
+// Parse parses args as [flag.FlagSet.Parse] does, then applies the FlargSet's extra rules.
+// A bad flag, or breaking one of the rules, is a [UsageError]. [flag] doesn't print its own complaint and usage for it,
+// since [Command.Execute] reports both to the ErrorStream. Only -h and -help still print usage to Output.
+func (fs *FlargSet) Parse(args []string) error {
+	var complaint bytes.Buffer
+	prev := fs.Output()
+	fs.SetOutput(&complaint)
+	err := fs.FlagSet.Parse(fs.rewritePrefixes(args))
+	fs.SetOutput(prev)
+	if errors.Is(err, flag.ErrHelp) {
+		prev.Write(complaint.Bytes())
+		return err
+	}
+	if err != nil {
+		return UsageError{err}
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+		if message, isDeprecated := fs.deprecated[f.Name]; isDeprecated {
+			fmt.Fprintf(fs.Output(), "warning: -%s is deprecated: %s\n", f.Name, message)
+		}
+	})
+	for _, group := range fs.exclusive {
+		var used []string
+		for _, name := range group {
+			if set[name] {
+				used = append(used, "-"+name)
+			}
+		}
+		if len(used) > 1 {
+			return UsageError{fmt.Errorf("flags %s are mutually exclusive", strings.Join(used, " and "))}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package flargs_test
+
+import (
+	"flag"
+	"slices"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestFlargSet_Deprecate(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fset := flargs.NewFlargSet("kat", flag.ContinueOnError)
+	fset.SetOutput(env.ErrorStream)
+	numbered := fset.Bool("n", false, "use numbering")
+	fset.Bool("number", false, "use numbering")
+	fset.Deprecate("number", "use -n instead")
+
+	if err := fset.Parse([]string{"-number", "-n", "-number", "a.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	if !*numbered {
+		t.Error("-n was not parsed")
+	}
+	want := "warning: -number is deprecated: use -n instead\n"
+	if got := string(env.GetError()); got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestFlargSet_MutuallyExclusive(t *testing.T) {
+
+	newSet := func() *flargs.FlargSet {
+		fset := flargs.NewFlargSet("report", flag.ContinueOnError)
+		fset.Bool("json", false, "output json")
+		fset.Bool("yaml", false, "output yaml")
+		fset.Bool("v", false, "verbose")
+		fset.MutuallyExclusive("json", "yaml")
+		return fset
+	}
+
+	t.Run("conflict", func(t *testing.T) {
+		err := newSet().Parse([]string{"-yaml", "-v", "-json"})
+		want := "flags -json and -yaml are mutually exclusive"
+		if err == nil || err.Error() != want {
+			t.Errorf("got %v but wanted %q", err, want)
+		}
+	})
+
+	t.Run("single", func(t *testing.T) {
+		if err := newSet().Parse([]string{"-json", "-v"}); err != nil {
+			t.Error(err)
+		}
+	})
+
+}
+
+func TestFlargSet_SetPrefixes(t *testing.T) {
+
+	fset := flargs.NewFlargSet("find", flag.ContinueOnError)
+	x := fset.Bool("x", false, "enable x")
+	y := fset.Bool("y", true, "enable y")
+	name := fset.String("name", "", "match on name")
+	fset.SetPrefixes('+', '-')
+
+	if err := fset.Parse([]string{"+x", "-name", "-y", "-y", "src", "+x"}); err != nil {
+		t.Fatal(err)
+	}
+	if !*x {
+		t.Error("+x did not set x to true")
+	}
+	if *y {
+		t.Error("-y did not set y to false")
+	}
+	if *name != "-y" {
+		t.Errorf("got -name %q but wanted %q", *name, "-y")
+	}
+	if got, want := fset.Args(), []string{"src", "+x"}; !slices.Equal(got, want) {
+		t.Errorf("got args %q but wanted %q", got, want)
+	}
+
+}
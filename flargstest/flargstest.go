@@ -0,0 +1,17 @@
+// Flargstest provides test helpers for commands built with flargs.
+// It lives apart from flargs so that programs don't import [testing].
+package flargstest
+
+import (
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+// AssertNoError fails t if anything was written to the Environment's ErrorStream, and shows what was
+func AssertNoError(t testing.TB, e *flargs.Environment) {
+	t.Helper()
+	if got := e.GetError(); len(got) > 0 {
+		t.Errorf("unexpected output on ErrorStream:\n%s", got)
+	}
+}
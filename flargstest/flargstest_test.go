@@ -0,0 +1,45 @@
+package flargstest_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+	"github.com/sean9999/go-flargs/flargstest"
+)
+
+// recorder is a [testing.TB] that records failures instead of reporting them
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, a ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, a...))
+}
+
+func TestAssertNoError(t *testing.T) {
+
+	t.Run("empty", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		rec := &recorder{TB: t}
+		flargstest.AssertNoError(rec, env)
+		if len(rec.failures) != 0 {
+			t.Errorf("got failures %q but wanted none", rec.failures)
+		}
+	})
+
+	t.Run("not empty", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		fmt.Fprintln(env.ErrorStream, "warning: stray")
+		rec := &recorder{TB: t}
+		flargstest.AssertNoError(rec, env)
+		if len(rec.failures) != 1 || !strings.Contains(rec.failures[0], "warning: stray") {
+			t.Errorf("got failures %q but wanted one showing the stray warning", rec.failures)
+		}
+	})
+
+}
@@ -0,0 +1,184 @@
+package flargstest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+// Golden compares the Environment's output with the golden file at path,
+// failing t with a unified diff if they differ. When update is true, the golden file is rewritten instead.
+// Golden files live on the real filesystem, whatever the Environment's Filesystem is, so a relative path
+// such as "testdata/kat.golden" is found beside the test, and updates outlive it.
+// By convention, update comes from a flag in the calling test package:
+//
+//	var update = flag.Bool("update", false, "rewrite golden files")
+//	...
+//	flargstest.Golden(t, env, "testdata/kat.golden", *update)
+func Golden(t testing.TB, e *flargs.Environment, path string, update bool) {
+	t.Helper()
+	got := e.GetOutput()
+	if update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("updating golden file: %v", err)
+			return
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("updating golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v", err)
+		return
+	}
+	if string(got) != string(want) {
+		t.Errorf("output does not match %s:\n%s", path, unifiedDiff(path, "output", string(want), string(got)))
+	}
+}
+
+// diffContext is how many unchanged lines surround each change in a hunk
+const diffContext = 3
+
+// an edit is one line of a diff: ' ' for unchanged, '-' for removed, '+' for added
+type edit struct {
+	op   byte
+	line string
+	a, b int // line numbers, from 0, in the old and new text
+}
+
+// splitLines splits s into lines, keeping a missing final newline visible
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	} else {
+		lines[len(lines)-1] += "\n\\ No newline at end of file\n"
+	}
+	return lines
+}
+
+// maxDiffCells bounds the table [diffLines] builds. Past it, the differing middle is shown
+// as removed and re-added wholesale, which is correct, if not minimal.
+const maxDiffCells = 1 << 22
+
+// diffLines finds an edit script from a to b. Lines the two share at either end are matched directly,
+// and what is left between is diffed by longest common subsequence, if that fits in maxDiffCells.
+func diffLines(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var edits []edit
+	for i := range prefix {
+		edits = append(edits, edit{' ', a[i], i, i})
+	}
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	var middle []edit
+	if (len(middleA)+1)*(len(middleB)+1) <= maxDiffCells {
+		middle = lcsDiff(middleA, middleB)
+	} else {
+		for i, line := range middleA {
+			middle = append(middle, edit{'-', line, i, 0})
+		}
+		for j, line := range middleB {
+			middle = append(middle, edit{'+', line, len(middleA), j})
+		}
+	}
+	for _, ed := range middle {
+		ed.a += prefix
+		ed.b += prefix
+		edits = append(edits, ed)
+	}
+	for k := suffix; k > 0; k-- {
+		edits = append(edits, edit{' ', a[len(a)-k], len(a) - k, len(b) - k})
+	}
+	return edits
+}
+
+// lcsDiff finds a shortest edit script from a to b by longest common subsequence
+func lcsDiff(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i], i, j})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j], i, j})
+			j++
+		}
+	}
+	return edits
+}
+
+// unifiedDiff renders the difference between want and got in unified format, as diff -u does
+func unifiedDiff(wantName, gotName, want, got string) string {
+	edits := diffLines(splitLines(want), splitLines(got))
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", wantName, gotName)
+	for start := 0; start < len(edits); {
+		//	find the next change, then gather it and its neighbours into a hunk
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+		first := max(start-diffContext, 0)
+		last, unchanged := start, 0
+		for k := start; k < len(edits) && unchanged <= 2*diffContext; k++ {
+			if edits[k].op == ' ' {
+				unchanged++
+			} else {
+				last, unchanged = k, 0
+			}
+		}
+		end := min(last+diffContext+1, len(edits))
+		var oldLines, newLines int
+		for _, ed := range edits[first:end] {
+			if ed.op != '+' {
+				oldLines++
+			}
+			if ed.op != '-' {
+				newLines++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", edits[first].a+1, oldLines, edits[first].b+1, newLines)
+		for _, ed := range edits[first:end] {
+			out.WriteByte(ed.op)
+			out.WriteString(ed.line)
+		}
+		start = end
+	}
+	return out.String()
+}
@@ -0,0 +1,81 @@
+package flargstest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+	"github.com/sean9999/go-flargs/flargstest"
+)
+
+func (r *recorder) Fatalf(format string, a ...any) {
+	r.Errorf(format, a...)
+}
+
+func TestGolden(t *testing.T) {
+
+	golden := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\n"
+
+	dir := t.TempDir()
+	count := filepath.Join(dir, "count.golden")
+	if err := os.WriteFile(count, []byte(golden), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("match", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		fmt.Fprint(env.OutputStream, golden)
+		rec := &recorder{TB: t}
+		flargstest.Golden(rec, env, count, false)
+		if len(rec.failures) != 0 {
+			t.Errorf("got failures %q but wanted none", rec.failures)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		fmt.Fprint(env.OutputStream, strings.Replace(golden, "seven", "SEVEN", 1))
+		rec := &recorder{TB: t}
+		flargstest.Golden(rec, env, count, false)
+		if len(rec.failures) != 1 {
+			t.Fatalf("got failures %q but wanted one", rec.failures)
+		}
+		wantDiff := "--- " + count + "\n+++ output\n@@ -4,5 +4,5 @@\n four\n five\n six\n-seven\n+SEVEN\n eight\n"
+		if !strings.HasSuffix(rec.failures[0], wantDiff) {
+			t.Errorf("got failure:\n%s\nbut wanted it to end with:\n%s", rec.failures[0], wantDiff)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		fmt.Fprint(env.OutputStream, "fresh\n")
+		rec := &recorder{TB: t}
+		fresh := filepath.Join(dir, "testdata", "new.golden")
+		flargstest.Golden(rec, env, fresh, true)
+		data, err := os.ReadFile(fresh)
+		if err != nil || string(data) != "fresh\n" || len(rec.failures) != 0 {
+			t.Errorf("got %q, %v and failures %q after updating", data, err, rec.failures)
+		}
+	})
+
+	t.Run("large output", func(t *testing.T) {
+		var want, got strings.Builder
+		for i := range 5000 {
+			fmt.Fprintf(&want, "line %d\n", i)
+			fmt.Fprintf(&got, "LINE %d\n", i)
+		}
+		big := filepath.Join(dir, "big.golden")
+		os.WriteFile(big, []byte(want.String()), 0644)
+		env := flargs.NewTestingEnvironment(nil)
+		fmt.Fprint(env.OutputStream, got.String())
+		rec := &recorder{TB: t}
+		flargstest.Golden(rec, env, big, false)
+		if len(rec.failures) != 1 || !strings.Contains(rec.failures[0], "\n-line 4999\n") || !strings.Contains(rec.failures[0], "\n+LINE 0\n") {
+			t.Errorf("got %d failures but wanted one with the whole output replaced", len(rec.failures))
+		}
+	})
+
+}
@@ -0,0 +1,211 @@
+package flargs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	rfs "github.com/sean9999/go-real-fs"
+)
+
+// FS returns a read-only view of the Filesystem, for code that wants an [fs.FS],
+// such as [text/template.ParseFS] or [net/http.FS]. It also satisfies [fs.ReadDirFS], [fs.StatFS] and [fs.ReadFileFS].
+// The view has no write methods and is rooted at WorkingDir, as if by [fs.Sub], so "." is the working directory.
+// Names are resolved as by [Environment.ResolvePath], so an absolute name like /etc/app.conf is also accepted.
+// Any other name that fails [fs.ValidPath] is rejected with [fs.ErrInvalid].
+func (e Environment) FS() fs.FS {
+	return readOnlyFS{sortedFS{e.Filesystem}, e.WorkingDir}
+}
+
+// sortedFS reads from a Filesystem, taking any name it does, and lists directories in order
+type sortedFS struct {
+	fsys rfs.WritableFs
+}
+
+var _ rfs.RealFS = sortedFS{}
+
+func (s sortedFS) Open(name string) (fs.File, error) {
+	return s.fsys.Open(name)
+}
+
+// ReadDir sorts entries by name, as [os.ReadDir] does, whatever order the Filesystem returns them in
+func (s sortedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := s.fsys.ReadDir(name)
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, err
+}
+
+func (s sortedFS) Stat(name string) (fs.FileInfo, error) {
+	return s.fsys.Stat(name)
+}
+
+func (s sortedFS) ReadFile(name string) ([]byte, error) {
+	return s.fsys.ReadFile(name)
+}
+
+// readOnlyFS is the sortedFS [Environment.FS] hands out, seen from the working directory wd
+type readOnlyFS struct {
+	sorted sortedFS
+	wd     string
+}
+
+var _ rfs.RealFS = readOnlyFS{}
+
+// resolve maps name to a path on the Filesystem, or reports the error for op if name is not one the view takes
+func (r readOnlyFS) resolve(op, name string) (string, error) {
+	valid := fs.ValidPath(name)
+	if path.IsAbs(name) {
+		valid = name == "/" || fs.ValidPath(name[1:])
+	}
+	if !valid {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return resolvePath(r.wd, name), nil
+}
+
+func (r readOnlyFS) Open(name string) (fs.File, error) {
+	name, err := r.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return r.sorted.Open(name)
+}
+
+func (r readOnlyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	name, err := r.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return r.sorted.ReadDir(name)
+}
+
+func (r readOnlyFS) Stat(name string) (fs.FileInfo, error) {
+	name, err := r.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return r.sorted.Stat(name)
+}
+
+func (r readOnlyFS) ReadFile(name string) ([]byte, error) {
+	name, err := r.resolve("read", name)
+	if err != nil {
+		return nil, err
+	}
+	return r.sorted.ReadFile(name)
+}
+
+// ReadDir lists the directory name on the Filesystem, sorted by name whatever the Filesystem's own order.
+// A relative name is resolved against WorkingDir.
+func (e Environment) ReadDir(name string) ([]fs.DirEntry, error) {
+	return sortedFS{e.Filesystem}.ReadDir(e.ResolvePath(name))
+}
+
+// Walk walks the Filesystem from root, as [fs.WalkDir] does, visiting entries in lexical order.
+// A relative root is resolved against WorkingDir.
+func (e Environment) Walk(root string, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(sortedFS{e.Filesystem}, e.ResolvePath(root), fn)
+}
+
+// WalkCtx is [Environment.Walk], but checks ctx before each entry, and stops with the context's error once it is done.
+// A nil ctx means the Environment's Context.
+func (e Environment) WalkCtx(ctx context.Context, root string, fn fs.WalkDirFunc) error {
+	ctx = e.ctxOr(ctx)
+	return e.Walk(root, func(p string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fn(p, d, err)
+	})
+}
+
+// WalkParallel calls fn for every entry under root, directories included, as [Environment.WalkCtx] would,
+// but across a pool of workers, so heavy per-entry work on a large tree isn't done one entry at a time.
+// Entries are not visited in any particular order. If fn fails, entries not yet started are skipped,
+// and of the failures, the one for the entry that comes first in lexical order is returned, so the same tree reports the same error every run.
+// Once ctx is done, WalkParallel stops with the context's error. A nil ctx means the Environment's Context.
+func (e Environment) WalkParallel(ctx context.Context, root string, workers int, fn func(path string, d fs.DirEntry) error) error {
+	ctx = e.ctxOr(ctx)
+	type entry struct {
+		path string
+		d    fs.DirEntry
+	}
+	var entries []entry
+	err := e.WalkCtx(ctx, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		entries = append(entries, entry{p, d})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	//	firstFailed is the lowest index that has failed so far. Entries before it are always visited,
+	//	which is what makes the reported error deterministic.
+	errs := make([]error, len(entries))
+	var firstFailed atomic.Int64
+	firstFailed.Store(int64(len(entries)))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				if int64(i) > firstFailed.Load() || ctx.Err() != nil {
+					continue
+				}
+				if errs[i] = fn(entries[i].path, entries[i].d); errs[i] != nil {
+					for failed := firstFailed.Load(); int64(i) < failed && !firstFailed.CompareAndSwap(failed, int64(i)); {
+						failed = firstFailed.Load()
+					}
+				}
+			}
+		}()
+	}
+	for i := range entries {
+		if int64(i) > firstFailed.Load() || ctx.Err() != nil {
+			break
+		}
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CopyFileCtx copies the file src to dst on the Filesystem, in chunks as [Environment.Copy] does,
+// stopping with the context's error once ctx is done. dst is created with [Environment.FileMode], or truncated.
+// A nil ctx means the Environment's Context.
+func (e Environment) CopyFileCtx(ctx context.Context, src, dst string) error {
+	in, err := e.Filesystem.Open(e.ResolvePath(src))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := e.Filesystem.OpenFile(e.ResolvePath(dst), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, e.FileMode())
+	if err != nil {
+		return err
+	}
+	_, err = copyCtx(e.ctxOr(ctx), out, in)
+	return errors.Join(err, out.Close())
+}
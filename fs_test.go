@@ -0,0 +1,226 @@
+package flargs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+	realfs "github.com/sean9999/go-real-fs"
+)
+
+func TestEnvironment_FS(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem.WriteFile("site/index.html", []byte("<h1>hi</h1>"), 0644)
+	env.Filesystem.WriteFile("site/css/main.css", []byte("h1 {}"), 0644)
+
+	fsys := env.FS()
+	if _, writable := fsys.(realfs.WritableFs); writable {
+		t.Error("FS exposes write methods")
+	}
+	if _, ok := fsys.(fs.ReadDirFS); !ok {
+		t.Error("FS is not an fs.ReadDirFS")
+	}
+	if _, ok := fsys.(fs.StatFS); !ok {
+		t.Error("FS is not an fs.StatFS")
+	}
+
+	var files []string
+	err := fs.WalkDir(fsys, "site", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"site/css/main.css", "site/index.html"}
+	if !slices.Equal(files, want) {
+		t.Errorf("got %q but wanted %q", files, want)
+	}
+
+	for _, name := range []string{"site/../site/index.html", "./site", "", "/site/../x"} {
+		if _, err := fsys.Open(name); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("Open(%q): got %v but wanted fs.ErrInvalid", name, err)
+		}
+		if _, err := fs.ReadFile(fsys, name); !errors.Is(err, fs.ErrInvalid) {
+			t.Errorf("ReadFile(%q): got %v but wanted fs.ErrInvalid", name, err)
+		}
+	}
+
+	if err := env.Chdir("site"); err != nil {
+		t.Fatal(err)
+	}
+	fsys = env.FS()
+	for name, want := range map[string]string{
+		"index.html":       "<h1>hi</h1>",
+		"css/main.css":     "h1 {}",
+		"/site/index.html": "<h1>hi</h1>",
+	} {
+		got, err := fs.ReadFile(fsys, name)
+		if err != nil || string(got) != want {
+			t.Errorf("ReadFile(%q): got %q, %v but wanted %q", name, got, err, want)
+		}
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("got %v, %v but wanted the two entries of the working directory", entries, err)
+	}
+
+}
+
+// reversedFS lists directories backwards, like a filesystem that returns entries in map order might
+type reversedFS struct {
+	realfs.WritableFs
+}
+
+func (r reversedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := r.WritableFs.ReadDir(name)
+	slices.Reverse(entries)
+	return entries, err
+}
+
+func TestEnvironment_ReadDir_sorted(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	for _, name := range []string{"docs/c.md", "docs/a.md", "docs/b/index.md", "docs/d.md"} {
+		env.Filesystem.WriteFile(name, nil, 0644)
+	}
+	env.Filesystem = reversedFS{env.Filesystem}
+
+	entries, err := env.ReadDir("docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if want := []string{"a.md", "b", "c.md", "d.md"}; !slices.Equal(names, want) {
+		t.Errorf("got %q but wanted %q", names, want)
+	}
+
+	var walked []string
+	err = env.Walk("docs", func(p string, d fs.DirEntry, err error) error {
+		walked = append(walked, p)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/docs", "/docs/a.md", "/docs/b", "/docs/b/index.md", "/docs/c.md", "/docs/d.md"}
+	if !slices.Equal(walked, want) {
+		t.Errorf("got %q but wanted %q", walked, want)
+	}
+
+}
+
+func TestEnvironment_WalkCtx(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		env.Filesystem.WriteFile("/data/"+name, nil, 0644)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var visited []string
+	err := env.WalkCtx(ctx, "/data", func(p string, d fs.DirEntry, err error) error {
+		visited = append(visited, path.Base(p))
+		if path.Base(p) == "b" {
+			cancel()
+		}
+		return err
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v but wanted %v", err, context.Canceled)
+	}
+	if want := []string{"data", "a", "b"}; !slices.Equal(visited, want) {
+		t.Errorf("visited %q but wanted to stop after %q", visited, want)
+	}
+
+}
+
+func TestEnvironment_WalkParallel(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	var files []string
+	for _, dir := range []string{"/tree", "/tree/x", "/tree/x/y", "/tree/z"} {
+		for i := range 10 {
+			name := fmt.Sprintf("%s/f%d", dir, i)
+			env.Filesystem.WriteFile(name, nil, 0644)
+			files = append(files, name)
+		}
+	}
+
+	t.Run("visits every file once", func(t *testing.T) {
+		var mu sync.Mutex
+		visits := map[string]int{}
+		err := env.WalkParallel(nil, "/tree", 4, func(p string, d fs.DirEntry) error {
+			if !d.IsDir() {
+				mu.Lock()
+				visits[p]++
+				mu.Unlock()
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(visits) != len(files) {
+			t.Errorf("visited %d files but wanted %d", len(visits), len(files))
+		}
+		for _, name := range files {
+			if visits[name] != 1 {
+				t.Errorf("%s: visited %d times", name, visits[name])
+			}
+		}
+	})
+
+	t.Run("reports the first error in lexical order", func(t *testing.T) {
+		for range 20 {
+			err := env.WalkParallel(nil, "/tree", 8, func(p string, d fs.DirEntry) error {
+				if p == "/tree/z/f3" || p == "/tree/f7" || p == "/tree/x/y/f0" {
+					return errors.New(p)
+				}
+				return nil
+			})
+			if got, want := fmt.Sprint(err), "/tree/f7"; got != want {
+				t.Fatalf("got %q but wanted %q", got, want)
+			}
+		}
+	})
+
+}
+
+func TestEnvironment_CopyFileCtx(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	big := bytes.Repeat([]byte("0123456789abcdef"), 10_000)
+	env.Filesystem.WriteFile("/big.bin", big, 0644)
+
+	if err := env.CopyFileCtx(nil, "/big.bin", "/copy.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := env.Filesystem.ReadFile("/copy.bin"); !bytes.Equal(got, big) {
+		t.Errorf("got %d bytes but wanted an identical copy of %d", len(got), len(big))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := env.CopyFileCtx(ctx, "/big.bin", "/cancelled.bin"); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v but wanted %v", err, context.Canceled)
+	}
+
+}
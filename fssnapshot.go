@@ -0,0 +1,93 @@
+package flargs
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+// ErrNotMemFS is returned by [Environment.SnapshotFS] and [Environment.RestoreFS] when the Filesystem is not, and does not wrap, a [*MemFS]
+var ErrNotMemFS = errors.New("snapshot: filesystem is not a MemFS")
+
+// FSSnapshot is the state of a Filesystem at one moment: every file, its contents and its mode.
+// Take one with [Environment.SnapshotFS] and go back to it with [Environment.RestoreFS].
+type FSSnapshot struct {
+	files map[string]snapshotFile
+}
+
+type snapshotFile struct {
+	data []byte
+	mode fs.FileMode
+}
+
+// Files returns the paths in the snapshot, sorted
+func (s FSSnapshot) Files() []string {
+	paths := make([]string, 0, len(s.files))
+	for p := range s.files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// files reads every file on the Filesystem, keyed by path
+func (e Environment) files() (map[string]snapshotFile, error) {
+	if _, ok := asMemFS(e.Filesystem); !ok {
+		return nil, ErrNotMemFS
+	}
+	files := map[string]snapshotFile{}
+	err := e.Walk("/", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := e.Filesystem.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files[p] = snapshotFile{data, info.Mode()}
+		return nil
+	})
+	return files, err
+}
+
+// SnapshotFS captures every file on the Filesystem, for a later [Environment.RestoreFS].
+// It reads everything from the root down, so it only works on a [*MemFS], or a wrapper around one such as a [TracingFS], and returns [ErrNotMemFS] for anything else.
+func (e Environment) SnapshotFS() (FSSnapshot, error) {
+	files, err := e.files()
+	if err != nil {
+		return FSSnapshot{}, err
+	}
+	return FSSnapshot{files}, nil
+}
+
+// RestoreFS puts the Filesystem back as it was when s was taken.
+// Files added since are removed, and the rest are rewritten with their old contents and modes.
+func (e *Environment) RestoreFS(s FSSnapshot) error {
+	current, err := e.files()
+	if err != nil {
+		return err
+	}
+	for p := range current {
+		if _, kept := s.files[p]; !kept {
+			if err := e.Filesystem.Remove(p); err != nil {
+				return err
+			}
+		}
+	}
+	for p, f := range s.files {
+		//	WriteFile keeps the mode of a file that exists, so one whose mode changed is removed first
+		if c, exists := current[p]; exists && c.mode != f.mode {
+			if err := e.Filesystem.Remove(p); err != nil {
+				return err
+			}
+		}
+		if err := e.Filesystem.WriteFile(p, f.data, f.mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
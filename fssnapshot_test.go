@@ -0,0 +1,82 @@
+package flargs_test
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+	realfs "github.com/sean9999/go-real-fs"
+)
+
+func TestEnvironment_RestoreFS(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem.WriteFile("/etc/app.conf", []byte("colour=blue"), 0600)
+	env.Filesystem.WriteFile("/data/notes.txt", []byte("original"), 0644)
+
+	snap, err := env.SnapshotFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env.Filesystem.WriteFile("/data/notes.txt", []byte("scribbled over"), 0644)
+	env.Filesystem.Remove("/etc/app.conf")
+	env.Filesystem.WriteFile("/data/new.txt", []byte("added"), 0644)
+	env.Filesystem.Remove("/data/notes.txt")
+	env.Filesystem.WriteFile("/data/notes.txt", []byte("scribbled over"), 0600)
+
+	if err := env.RestoreFS(snap); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := env.SnapshotFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := after.Files(), []string{"/data/notes.txt", "/etc/app.conf"}; !slices.Equal(got, want) {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	for name, want := range map[string]string{
+		"/data/notes.txt": "original",
+		"/etc/app.conf":   "colour=blue",
+	} {
+		got, err := env.Filesystem.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: got %q but wanted %q", name, got, want)
+		}
+	}
+	info, err := env.Filesystem.Stat("/etc/app.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode().Perm(), fs.FileMode(0600); got != want {
+		t.Errorf("got mode %v but wanted %v", got, want)
+	}
+	info, err = env.Filesystem.Stat("/data/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := info.Mode().Perm(), fs.FileMode(0644); got != want {
+		t.Errorf("got mode %v but wanted %v", got, want)
+	}
+
+}
+
+func TestEnvironment_SnapshotFS_notMemFS(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem = flargs.TracingFS(realfs.NewTestFs(), io.Discard)
+	if _, err := env.SnapshotFS(); !errors.Is(err, flargs.ErrNotMemFS) {
+		t.Errorf("got %v but wanted ErrNotMemFS", err)
+	}
+	if err := env.RestoreFS(flargs.FSSnapshot{}); !errors.Is(err, flargs.ErrNotMemFS) {
+		t.Errorf("got %v but wanted ErrNotMemFS", err)
+	}
+
+}
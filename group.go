@@ -0,0 +1,90 @@
+package flargs
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// a Group runs subtasks concurrently, at most limit at a time, and collects the first error.
+// It is bound to the Environment's Context: once that is done, or any subtask fails,
+// subtasks that haven't started yet are skipped.
+type Group struct {
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	wg      sync.WaitGroup
+	sem     chan struct{}
+	errOnce sync.Once
+	err     error
+	out     io.Writer
+	errOut  io.Writer
+}
+
+// Group returns a [Group] running at most limit subtasks at once. A limit below 1 means no limit.
+func (e Environment) Group(limit int) *Group {
+	ctx, cancel := context.WithCancelCause(e.ctxOr(nil))
+	g := &Group{
+		ctx:    ctx,
+		cancel: cancel,
+		out:    SyncWriter(e.OutputStream),
+		errOut: SyncWriter(e.ErrorStream),
+	}
+	if limit > 0 {
+		g.sem = make(chan struct{}, limit)
+	}
+	return g
+}
+
+// Context is done when the Environment's Context is, or when a subtask has failed
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Output is a [SyncWriter] over the Environment's OutputStream, shared by all subtasks
+func (g *Group) Output() io.Writer {
+	return g.out
+}
+
+// Errors is a [SyncWriter] over the Environment's ErrorStream, shared by all subtasks
+func (g *Group) Errors() io.Writer {
+	return g.errOut
+}
+
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel(err)
+	})
+}
+
+// Go runs fn in a new goroutine once a slot is free.
+// It does not block.
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			select {
+			case g.sem <- struct{}{}:
+				defer func() { <-g.sem }()
+			case <-g.ctx.Done():
+				g.fail(context.Cause(g.ctx))
+				return
+			}
+		}
+		if g.ctx.Err() != nil {
+			g.fail(context.Cause(g.ctx))
+			return
+		}
+		if err := fn(); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// Wait blocks until every subtask has returned or been skipped, and returns the first error
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(nil)
+	return g.err
+}
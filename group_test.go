@@ -0,0 +1,76 @@
+package flargs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_Group(t *testing.T) {
+
+	t.Run("limit", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		g := env.Group(2)
+		var running, peak atomic.Int32
+		for i := range 8 {
+			g.Go(func() error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				fmt.Fprintf(g.Output(), "task %d\n", i)
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		if p := peak.Load(); p > 2 {
+			t.Errorf("%d subtasks ran at once, but the limit was 2", p)
+		}
+		if lines := strings.Count(string(env.GetOutput()), "\n"); lines != 8 {
+			t.Errorf("got %d lines of output but wanted 8", lines)
+		}
+	})
+
+	t.Run("first error", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		g := env.Group(0)
+		boom := errors.New("boom")
+		g.Go(func() error {
+			<-g.Context().Done()
+			return errors.New("later")
+		})
+		g.Go(func() error { return boom })
+		if err := g.Wait(); !errors.Is(err, boom) {
+			t.Errorf("got %v but wanted %v", err, boom)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		env := flargs.NewTestingEnvironment(nil).WithContext(ctx)
+		g := env.Group(0)
+		ran := false
+		g.Go(func() error { ran = true; return nil })
+		if err := g.Wait(); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v but wanted %v", err, context.Canceled)
+		}
+		if ran {
+			t.Error("subtask ran after the context was done")
+		}
+	})
+
+}
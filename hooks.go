@@ -0,0 +1,13 @@
+package flargs
+
+// OnBeforeRun registers fn to be called by [Command.Execute] before the command is parsed and run.
+// Hooks are called in the order they were registered.
+func (e *Environment) OnBeforeRun(fn func(*Environment)) {
+	e.beforeRun = append(e.beforeRun, fn)
+}
+
+// OnAfterRun registers fn to be called by [Command.Execute] with the exit code, as an int, once the command has finished,
+// before its streams are closed. Hooks are called in the order they were registered.
+func (e *Environment) OnAfterRun(fn func(*Environment, int)) {
+	e.afterRun = append(e.afterRun, fn)
+}
@@ -0,0 +1,37 @@
+package flargs_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_OnAfterRun(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	var calls []string
+	env.OnBeforeRun(func(*flargs.Environment) { calls = append(calls, "before 1") })
+	env.OnBeforeRun(func(*flargs.Environment) { calls = append(calls, "before 2") })
+	env.OnAfterRun(func(_ *flargs.Environment, code int) {
+		calls = append(calls, fmt.Sprintf("after 1: %d", code))
+	})
+	env.OnAfterRun(func(_ *flargs.Environment, code int) {
+		calls = append(calls, fmt.Sprintf("after 2: %d", code))
+	})
+
+	cmd := flargs.CommandFunc(func(*flargs.Environment) error {
+		calls = append(calls, "run")
+		return flargs.NewFlargError(flargs.ExitCodeCommandNotFound, errors.New("nope"))
+	})
+	cmd.Environment = env
+	cmd.Execute(nil)
+
+	want := []string{"before 1", "before 2", "run", "after 1: 127", "after 2: 127"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("got %q but wanted %q", calls, want)
+	}
+
+}
@@ -0,0 +1,8 @@
+//go:build js
+
+package flargs
+
+import "os"
+
+// notifyHUP does nothing, since there is no SIGHUP under js/wasm
+func notifyHUP(_ chan<- os.Signal) {}
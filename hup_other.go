@@ -0,0 +1,14 @@
+//go:build !js
+
+package flargs
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyHUP relays SIGHUP to c, as [signal.Notify] does
+func notifyHUP(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGHUP)
+}
@@ -0,0 +1,39 @@
+package flargs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// invocation is the JSON form read by [Environment.ApplyInvocation]
+type invocation struct {
+	Args  []string          `json:"args"`
+	Vars  map[string]string `json:"vars"`
+	Stdin *string           `json:"stdin"`
+}
+
+// ApplyInvocation sets the Environment up from a JSON invocation, like
+// {"args": ["kat", "-n", "a.txt"], "vars": {"LANG": "C"}, "stdin": "..."},
+// so that command runs can be queued as messages and handed to workers.
+// args replaces Arguments, so it begins with the program name, as [os.Args] does.
+// vars are added to Variables, replacing any already there, and stdin, when present, becomes InputStream.
+func (e *Environment) ApplyInvocation(r io.Reader) error {
+	var inv invocation
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&inv); err != nil {
+		return fmt.Errorf("invocation: %w", err)
+	}
+	if inv.Args != nil {
+		e.Arguments = inv.Args
+	}
+	for k, v := range inv.Vars {
+		e.SetVar(k, v)
+	}
+	if inv.Stdin != nil {
+		e.InputStream = bytes.NewBufferString(*inv.Stdin)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package flargs_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_ApplyInvocation(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Variables["LANG"] = "en_CA"
+	blob := `{"args": ["kat", "-n", "-"], "vars": {"LANG": "C", "TZ": "UTC"}, "stdin": "hello\n"}`
+
+	if err := env.ApplyInvocation(strings.NewReader(blob)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"kat", "-n", "-"}; !slices.Equal(env.Arguments, want) {
+		t.Errorf("got arguments %q but wanted %q", env.Arguments, want)
+	}
+	if env.Variables["LANG"] != "C" || env.Variables["TZ"] != "UTC" {
+		t.Errorf("got LANG=%q TZ=%q but wanted C and UTC", env.Variables["LANG"], env.Variables["TZ"])
+	}
+	if got := string(env.GetInput()); got != "hello\n" {
+		t.Errorf("got input %q but wanted %q", got, "hello\n")
+	}
+
+	if err := env.ApplyInvocation(strings.NewReader(`{"argv": []}`)); err == nil {
+		t.Error("wanted an error for an unknown field")
+	}
+
+}
@@ -0,0 +1,82 @@
+package flargs
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// TransformLines reads InputStream line by line, passes each line through fn,
+// and writes the results to OutputStream, one per line. Lines may be of any length.
+// When fn fails, the error is written to ErrorStream with its line number, the line is dropped, and processing continues.
+// The returned error joins every per-line error. [Command.Execute] knows they have been reported, and doesn't repeat them.
+// fn sees everything before the newline, so a carriage return is kept.
+func (e Environment) TransformLines(fn func(line string) (string, error)) error {
+	return e.TransformLinesWith(BatchCollectErrors, fn)
+}
+
+// TransformLinesWith is [Environment.TransformLines], with policy deciding whether a failing line stops processing.
+// Under [BatchAbortOnError], the first line error is written to ErrorStream and returned.
+func (e Environment) TransformLinesWith(policy BatchPolicy, fn func(line string) (string, error)) error {
+	var errs []error
+	r := bufio.NewReader(e.InputStream)
+	lineNumber := 0
+	for {
+		line, readErr := r.ReadString('\n')
+		if line == "" && readErr != nil {
+			if readErr != io.EOF {
+				errs = append(errs, readErr)
+			}
+			break
+		}
+		lineNumber++
+		line = strings.TrimSuffix(line, "\n")
+		out, err := fn(line)
+		if err != nil {
+			err = fmt.Errorf("line %d: %w", lineNumber, err)
+			fmt.Fprintln(e.ErrorStream, err)
+			if policy == BatchAbortOnError {
+				return reportedError{err}
+			}
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := fmt.Fprintln(e.OutputStream, out); err != nil {
+			return err
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return reportedError{err}
+	}
+	return nil
+}
+
+// CountInput reads InputStream to the end and tallies it the way wc does.
+// lines is the number of newlines, so a final line without one isn't counted,
+// and words are runs of non-space characters.
+func (e Environment) CountInput() (lines, words, bytes int64, err error) {
+	r := bufio.NewReader(e.InputStream)
+	inWord := false
+	for {
+		c, size, err := r.ReadRune()
+		if err == io.EOF {
+			return lines, words, bytes, nil
+		}
+		if err != nil {
+			return lines, words, bytes, err
+		}
+		bytes += int64(size)
+		if c == '\n' {
+			lines++
+		}
+		if unicode.IsSpace(c) {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			words++
+		}
+	}
+}
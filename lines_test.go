@@ -0,0 +1,104 @@
+package flargs_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_TransformLines(t *testing.T) {
+
+	upper := func(line string) (string, error) {
+		if line == "bad" {
+			return "", errors.New("refusing to shout")
+		}
+		return strings.ToUpper(line), nil
+	}
+
+	t.Run("continue past errors", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write([]byte("hello\nbad\nworld\n"))
+		env.InputStream.Write([]byte("bad"))
+		err := env.TransformLines(upper)
+		if got, want := fmt.Sprint(err), "line 2: refusing to shout\nline 4: refusing to shout"; got != want {
+			t.Errorf("got error %q but wanted %q", got, want)
+		}
+		if got, want := string(env.GetOutput()), "HELLO\nWORLD\n"; got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+		if got, want := string(env.GetError()), "line 2: refusing to shout\nline 4: refusing to shout\n"; got != want {
+			t.Errorf("got stderr %q but wanted %q", got, want)
+		}
+	})
+
+	t.Run("reported once", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write([]byte("hello\nbad\nworld\n"))
+		cmd := flargs.CommandFunc(func(e *flargs.Environment) error {
+			return e.TransformLines(upper)
+		})
+		cmd.Environment = env
+		if code := cmd.Execute(nil); code != flargs.ExitCodeGenericError {
+			t.Errorf("got exit code %d", code)
+		}
+		if got, want := string(env.GetError()), "line 2: refusing to shout\n"; got != want {
+			t.Errorf("got stderr %q but wanted %q", got, want)
+		}
+	})
+
+	t.Run("long lines", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		long := strings.Repeat("x", 1<<20)
+		env.InputStream.Write([]byte(long + "\r\nshort\n"))
+		if err := env.TransformLines(upper); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(env.GetOutput()), strings.ToUpper(long)+"\r\nSHORT\n"; got != want {
+			t.Errorf("got %d bytes but wanted %d", len(got), len(want))
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write([]byte("hello\nbad\nworld\nbad\n"))
+		if err := env.TransformLinesWith(flargs.BatchAbortOnError, upper); err == nil {
+			t.Error("wanted an error")
+		}
+		if got, want := string(env.GetError()), "line 2: refusing to shout\n"; got != want {
+			t.Errorf("got stderr %q but wanted %q", got, want)
+		}
+		if got, want := string(env.GetOutput()), "HELLO\n"; got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+	})
+
+}
+
+func TestEnvironment_CountInput(t *testing.T) {
+
+	cases := []struct {
+		input               string
+		lines, words, bytes int64
+	}{
+		{"", 0, 0, 0},
+		{"hello world\nsecond  line\n", 2, 4, 25},
+		{"no trailing\nnewline", 1, 3, 19},
+		{"  \t\n\n", 2, 0, 5},
+		{"héllo wörld\n", 1, 2, 14},
+	}
+	for _, tc := range cases {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write([]byte(tc.input))
+		lines, words, bytes, err := env.CountInput()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines != tc.lines || words != tc.words || bytes != tc.bytes {
+			t.Errorf("%q: got %d %d %d but wanted %d %d %d", tc.input, lines, words, bytes, tc.lines, tc.words, tc.bytes)
+		}
+	}
+
+}
@@ -0,0 +1,96 @@
+package flargs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Verbosity is how chatty the command should be. It is the number of -v flags in Arguments,
+// counting -vv as two and --verbose as one, or, failing that, the integer in FLARGS_VERBOSITY.
+// The default is 0.
+func (e Environment) Verbosity() int {
+	n := 0
+	for _, arg := range e.args() {
+		if arg == "--" {
+			break
+		}
+		if arg == "--verbose" {
+			n++
+		} else if len(arg) > 1 && arg[0] == '-' && strings.Trim(arg[1:], "v") == "" {
+			n += len(arg) - 1
+		}
+	}
+	if n > 0 {
+		return n
+	}
+	v, _ := strconv.Atoi(e.Variables["FLARGS_VERBOSITY"])
+	return v
+}
+
+// logLevels is the verbosity at which each level is emitted.
+// An unknown level is treated like info.
+var logLevels = map[string]int{
+	"error": 0,
+	"warn":  0,
+	"info":  1,
+	"debug": 2,
+	"trace": 3,
+}
+
+// logValue quotes s if it would be ambiguous in a key=value line
+func logValue(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// Logf writes msg and the key/value pairs in kv as one line to ErrorStream,
+// like `level=debug msg="cache miss" key=README.md`,
+// if [Environment.Verbosity] is high enough for level.
+// error and warn are always written. info needs -v, debug -vv and trace -vvv.
+func (e Environment) Logf(level, msg string, kv ...any) {
+	threshold, known := logLevels[level]
+	if !known {
+		threshold = logLevels["info"]
+	}
+	if e.Verbosity() < threshold {
+		return
+	}
+	var line strings.Builder
+	fmt.Fprintf(&line, "level=%s msg=%s", logValue(level), logValue(msg))
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fmt.Fprintf(&line, " !BADKEY=%s", logValue(fmt.Sprint(kv[i])))
+			break
+		}
+		fmt.Fprintf(&line, " %s=%s", logValue(fmt.Sprint(kv[i])), logValue(fmt.Sprint(kv[i+1])))
+	}
+	fmt.Fprintln(e.ErrorStream, line.String())
+}
+
+// Quiet reports whether informational output should be suppressed,
+// because of a -q or --quiet argument, or FLARGS_QUIET being true, as understood by [Environment.GetBool].
+// A FLARGS_QUIET that isn't a boolean counts as false.
+func (e Environment) Quiet() bool {
+	if e.hasFlag("-q", "--quiet") {
+		return true
+	}
+	on, _ := e.GetBool("FLARGS_QUIET")
+	return on
+}
+
+// Info is [Environment.Printf], unless the Environment is [Environment.Quiet]
+func (e Environment) Info(format string, a ...any) {
+	if e.Quiet() {
+		return
+	}
+	e.Printf(format, a...)
+}
+
+// Error is [Environment.Eprintf], which writes even when the Environment is [Environment.Quiet].
+// It is kept as the counterpart of [Environment.Info].
+func (e Environment) Error(format string, a ...any) {
+	e.Eprintf(format, a...)
+}
@@ -0,0 +1,84 @@
+package flargs_test
+
+import (
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_Verbosity(t *testing.T) {
+
+	table := []struct {
+		args []string
+		vars map[string]string
+		want int
+	}{
+		{nil, nil, 0},
+		{[]string{"-v"}, nil, 1},
+		{[]string{"-vv", "--verbose", "file.txt"}, nil, 3},
+		{[]string{"--", "-vvv"}, nil, 0},
+		{nil, map[string]string{"FLARGS_VERBOSITY": "2"}, 2},
+	}
+
+	for _, row := range table {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Arguments = append([]string{"prog"}, row.args...)
+		for k, v := range row.vars {
+			env.Variables[k] = v
+		}
+		if got := env.Verbosity(); got != row.want {
+			t.Errorf("%q %v: got %d but wanted %d", row.args, row.vars, got, row.want)
+		}
+	}
+
+}
+
+func TestEnvironment_Logf(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Arguments = []string{"prog", "-v"}
+	env.Logf("debug", "cache miss", "key", "README.md")
+	if got := env.GetError(); len(got) != 0 {
+		t.Errorf("got %q but wanted debug suppressed at -v", got)
+	}
+
+	env.Arguments = []string{"prog", "-vv"}
+	env.Logf("debug", "cache miss", "key", "README.md", "size", 42)
+	want := "level=debug msg=\"cache miss\" key=README.md size=42\n"
+	if got := string(env.GetError()); got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestEnvironment_Quiet(t *testing.T) {
+
+	for name, setup := range map[string]func(*flargs.Environment){
+		"-q":           func(e *flargs.Environment) { e.Arguments = []string{"prog", "-q"} },
+		"--quiet":      func(e *flargs.Environment) { e.Arguments = []string{"prog", "--quiet"} },
+		"FLARGS_QUIET": func(e *flargs.Environment) { e.Variables["FLARGS_QUIET"] = "1" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			setup(env)
+			env.Info("copied %d files\n", 3)
+			env.Error("skipped %s\n", "a.txt")
+			if got := env.OutputString(); got != "" {
+				t.Errorf("got %q but wanted Info suppressed", got)
+			}
+			if got, want := string(env.GetError()), "skipped a.txt\n"; got != want {
+				t.Errorf("got %q but wanted %q", got, want)
+			}
+		})
+	}
+
+	for _, value := range []string{"false", "False", "0", "no", "off", "maybe"} {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Variables["FLARGS_QUIET"] = value
+		env.Info("copied %d files\n", 3)
+		if got, want := env.OutputString(), "copied 3 files\n"; got != want {
+			t.Errorf("FLARGS_QUIET=%s: got %q but wanted %q", value, got, want)
+		}
+	}
+
+}
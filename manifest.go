@@ -0,0 +1,71 @@
+package flargs
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// artifacts are the files recorded by [Environment.RecordArtifact]
+type artifacts struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+func newArtifacts() *artifacts {
+	return &artifacts{paths: map[string]bool{}}
+}
+
+// artifactSet returns the Environment's artifacts, creating them on first use
+func (e *Environment) artifactSet() *artifacts {
+	lazyMu.Lock()
+	defer lazyMu.Unlock()
+	if e.artifacts == nil {
+		e.artifacts = newArtifacts()
+	}
+	return e.artifacts
+}
+
+// RecordArtifact notes that the command produced the file at path, for [Environment.WriteManifest].
+// A relative path is resolved against WorkingDir, so recording the same file twice, by any path, has no extra effect.
+func (e *Environment) RecordArtifact(path string) {
+	a := e.artifactSet()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.paths[e.ResolvePath(path)] = true
+}
+
+// Artifacts returns the recorded paths, sorted
+func (e *Environment) Artifacts() []string {
+	a := e.artifactSet()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	paths := make([]string, 0, len(a.paths))
+	for p := range a.paths {
+		paths = append(paths, p)
+	}
+	slices.Sort(paths)
+	return paths
+}
+
+// WriteManifest writes the recorded artifacts to path on the Filesystem, as a sorted JSON array
+func (e *Environment) WriteManifest(path string) error {
+	data, err := json.MarshalIndent(e.Artifacts(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return e.Filesystem.WriteFile(e.ResolvePath(path), append(data, '\n'), e.FileMode())
+}
+
+// ReadManifest reads a manifest written by [Environment.WriteManifest]
+func (e Environment) ReadManifest(path string) ([]string, error) {
+	data, err := e.Filesystem.ReadFile(e.ResolvePath(path))
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	if err := json.Unmarshal(data, &paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
@@ -0,0 +1,44 @@
+package flargs_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_WriteManifest(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.RecordArtifact("/dist/b.tar.gz")
+	env.RecordArtifact("/dist/a.tar.gz")
+	env.RecordArtifact("/dist/b.tar.gz")
+	env.WorkingDir = "/dist"
+	env.RecordArtifact("c.tar.gz")
+	env.RecordArtifact("../dist/a.tar.gz")
+
+	if err := env.WriteManifest("/dist/manifest.json"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := env.ReadManifest("/dist/manifest.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"/dist/a.tar.gz", "/dist/b.tar.gz", "/dist/c.tar.gz"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestEnvironment_RecordArtifact_handBuilt(t *testing.T) {
+
+	env := &flargs.Environment{WorkingDir: "/dist"}
+	env.RecordArtifact("a.tar.gz")
+	env.RecordArtifact("/dist/b.tar.gz")
+	want := []string{"/dist/a.tar.gz", "/dist/b.tar.gz"}
+	if got := env.Artifacts(); !slices.Equal(got, want) {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
@@ -0,0 +1,472 @@
+package flargs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	rfs "github.com/sean9999/go-real-fs"
+)
+
+// MemFS is an in-memory [rfs.WritableFs].
+// Unlike [rfs.TestFS], its OpenFile honours O_APPEND, O_CREATE, O_TRUNC and O_EXCL the way [os.OpenFile] does.
+// Directories are implicit: a directory exists whenever a file exists beneath it.
+// Modification times come from Clock, so they are as reproducible as the Clock is.
+// The zero value is an empty MemFS stamped by the wall clock. It is safe for concurrent use.
+type MemFS struct {
+	Clock Clock
+	mu    sync.RWMutex
+	files map[string]*memFile
+}
+
+var _ rfs.WritableFs = (*MemFS)(nil)
+
+type memFile struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemFS creates an empty [MemFS] stamped by the [SystemClock]
+func NewMemFS() *MemFS {
+	return &MemFS{Clock: SystemClock{}, files: map[string]*memFile{}}
+}
+
+// fsUnwrapper is a Filesystem wrapped around another, such as a [TracingFS], that can say which
+type fsUnwrapper interface {
+	Unwrap() rfs.WritableFs
+}
+
+// asMemFS finds the [*MemFS] fsys is, or wraps
+func asMemFS(fsys rfs.WritableFs) (*MemFS, bool) {
+	for {
+		switch f := fsys.(type) {
+		case *MemFS:
+			return f, true
+		case fsUnwrapper:
+			fsys = f.Unwrap()
+		default:
+			return nil, false
+		}
+	}
+}
+
+func (m *MemFS) now() time.Time {
+	if m.Clock == nil {
+		return time.Now()
+	}
+	return m.Clock.Now()
+}
+
+var errNotWritable = errors.New("file not opened for writing")
+var errNotReadable = errors.New("file not opened for reading")
+
+// kindError is a name already in use as the other kind, a file or a directory. It counts as [fs.ErrExist].
+type kindError string
+
+func (k kindError) Error() string {
+	return string(k)
+}
+
+func (k kindError) Is(target error) bool {
+	return target == fs.ErrExist
+}
+
+const (
+	errIsDir  = kindError("is a directory")
+	errNotDir = kindError("not a directory")
+)
+
+// memKey turns any path into the key it is stored under.
+// Leading slashes are dropped, so "/a/b" and "a/b" are the same file.
+func memKey(op, name string) (string, error) {
+	key := strings.TrimLeft(cleanPath(name), "/")
+	if key == "" {
+		key = "."
+	}
+	if !fs.ValidPath(key) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return key, nil
+}
+
+// canCreate reports why a file can't be made at key, if it can't: key is a directory, or a parent of key is a file.
+// Callers hold the lock.
+func (m *MemFS) canCreate(key string) error {
+	if m.isDir(key) {
+		return errIsDir
+	}
+	for parent := path.Dir(key); parent != "."; parent = path.Dir(parent) {
+		if _, isFile := m.files[parent]; isFile {
+			return errNotDir
+		}
+	}
+	return nil
+}
+
+// create stores f at key, making the table of files if this is the first. Callers hold the lock.
+func (m *MemFS) create(key string, f *memFile) {
+	if m.files == nil {
+		m.files = map[string]*memFile{}
+	}
+	m.files[key] = f
+}
+
+// isDir reports whether key is the root or has files beneath it. Callers hold the lock.
+func (m *MemFS) isDir(key string) bool {
+	if key == "." {
+		return true
+	}
+	prefix := key + "/"
+	for k := range m.files {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemFS) Open(name string) (fs.File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (m *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (rfs.WritableFile, error) {
+	key, err := memKey("open", name)
+	if err != nil {
+		return nil, err
+	}
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, exists := m.files[key]
+	if !exists && m.isDir(key) {
+		if writable {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+		}
+		return &memDir{fsys: m, key: key}, nil
+	}
+	switch {
+	case exists && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case !exists && flag&os.O_CREATE == 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case !exists:
+		if err := m.canCreate(key); err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		f = &memFile{mode: perm.Perm(), modTime: m.now()}
+		m.create(key, f)
+	}
+	if writable && flag&os.O_TRUNC != 0 {
+		f.data = nil
+		f.modTime = m.now()
+	}
+	return &memHandle{
+		fsys:     m,
+		name:     name,
+		key:      key,
+		file:     f,
+		readable: flag&os.O_WRONLY == 0,
+		writable: writable,
+		append:   flag&os.O_APPEND != 0,
+	}, nil
+}
+
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	key, err := memKey("write", name)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if f, exists := m.files[key]; exists {
+		f.data = append([]byte(nil), data...)
+		f.modTime = m.now()
+		return nil
+	}
+	if err := m.canCreate(key); err != nil {
+		return &fs.PathError{Op: "write", Path: name, Err: err}
+	}
+	m.create(key, &memFile{
+		data:    append([]byte(nil), data...),
+		mode:    perm.Perm(),
+		modTime: m.now(),
+	})
+	return nil
+}
+
+func (m *MemFS) ReadFile(name string) ([]byte, error) {
+	key, err := memKey("read", name)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, exists := m.files[key]
+	if !exists {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), f.data...), nil
+}
+
+func (m *MemFS) Remove(name string) error {
+	key, err := memKey("remove", name)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.files[key]; !exists {
+		if m.isDir(key) {
+			return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+		}
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, key)
+	return nil
+}
+
+// Rename moves the file oldname to newname, replacing anything already there, as [os.Rename] does
+func (m *MemFS) Rename(oldname, newname string) error {
+	oldKey, err := memKey("rename", oldname)
+	if err != nil {
+		return err
+	}
+	newKey, err := memKey("rename", newname)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, exists := m.files[oldKey]
+	if !exists {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: fs.ErrNotExist}
+	}
+	if err := m.canCreate(newKey); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	delete(m.files, oldKey)
+	m.files[newKey] = f
+	return nil
+}
+
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	key, err := memKey("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if f, exists := m.files[key]; exists {
+		return f.info(key), nil
+	}
+	if m.isDir(key) {
+		return memDirInfo(key), nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir lists the immediate children of name, sorted by name
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	key, err := memKey("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, isFile := m.files[key]; isFile || !m.isDir(key) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	prefix := key + "/"
+	if key == "." {
+		prefix = ""
+	}
+	seen := map[string]fs.DirEntry{}
+	for k, f := range m.files {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		child, _, nested := strings.Cut(k[len(prefix):], "/")
+		if nested {
+			seen[child] = fs.FileInfoToDirEntry(memDirInfo(prefix + child))
+		} else {
+			seen[child] = fs.FileInfoToDirEntry(f.info(k))
+		}
+	}
+	entries := make([]fs.DirEntry, 0, len(seen))
+	for _, entry := range seen {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// memInfo implements [fs.FileInfo]
+type memInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return i.mode }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memInfo) Sys() any           { return nil }
+
+// info describes f. Callers hold the lock.
+func (f *memFile) info(key string) memInfo {
+	return memInfo{path.Base(key), int64(len(f.data)), f.mode, f.modTime}
+}
+
+func memDirInfo(key string) memInfo {
+	return memInfo{name: path.Base(key), mode: fs.ModeDir | 0755}
+}
+
+// memHandle is an open file in a [MemFS]
+type memHandle struct {
+	fsys     *MemFS
+	name     string
+	key      string
+	file     *memFile
+	offset   int64
+	readable bool
+	writable bool
+	append   bool
+	closed   bool
+}
+
+// Name is the name the file was opened with, as with [os.File.Name]
+func (h *memHandle) Name() string {
+	return h.name
+}
+
+func (h *memHandle) Stat() (fs.FileInfo, error) {
+	h.fsys.mu.RLock()
+	defer h.fsys.mu.RUnlock()
+	return h.file.info(h.key), nil
+}
+
+func (h *memHandle) Read(p []byte) (int, error) {
+	if h.closed {
+		return 0, fs.ErrClosed
+	}
+	if !h.readable {
+		return 0, &fs.PathError{Op: "read", Path: h.key, Err: errNotReadable}
+	}
+	h.fsys.mu.RLock()
+	defer h.fsys.mu.RUnlock()
+	if h.offset >= int64(len(h.file.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, h.file.data[h.offset:])
+	h.offset += int64(n)
+	return n, nil
+}
+
+func (h *memHandle) Write(p []byte) (int, error) {
+	if h.closed {
+		return 0, fs.ErrClosed
+	}
+	if !h.writable {
+		return 0, &fs.PathError{Op: "write", Path: h.key, Err: errNotWritable}
+	}
+	h.fsys.mu.Lock()
+	defer h.fsys.mu.Unlock()
+	if h.append {
+		h.offset = int64(len(h.file.data))
+	}
+	end := h.offset + int64(len(p))
+	if end > int64(len(h.file.data)) {
+		grown := make([]byte, end)
+		copy(grown, h.file.data)
+		h.file.data = grown
+	}
+	copy(h.file.data[h.offset:], p)
+	h.offset = end
+	h.file.modTime = h.fsys.now()
+	return len(p), nil
+}
+
+func (h *memHandle) Seek(offset int64, whence int) (int64, error) {
+	if h.closed {
+		return 0, fs.ErrClosed
+	}
+	h.fsys.mu.RLock()
+	size := int64(len(h.file.data))
+	h.fsys.mu.RUnlock()
+	switch whence {
+	case io.SeekCurrent:
+		offset += h.offset
+	case io.SeekEnd:
+		offset += size
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: h.key, Err: fs.ErrInvalid}
+	}
+	h.offset = offset
+	return offset, nil
+}
+
+func (h *memHandle) Close() error {
+	if h.closed {
+		return fs.ErrClosed
+	}
+	h.closed = true
+	return nil
+}
+
+// memDir is an open directory in a [MemFS]
+type memDir struct {
+	fsys    *MemFS
+	key     string
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *memDir) Name() string                       { return d.key }
+func (d *memDir) Stat() (fs.FileInfo, error)         { return memDirInfo(d.key), nil }
+func (d *memDir) Write(_ []byte) (int, error)        { return 0, errNotWritable }
+func (d *memDir) Seek(_ int64, _ int) (int64, error) { return 0, nil }
+func (d *memDir) Close() error                       { return nil }
+
+func (d *memDir) Read(_ []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.key, Err: errors.New("is a directory")}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fsys.ReadDir(d.key)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -0,0 +1,199 @@
+package flargs_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestMemFS_OpenFile(t *testing.T) {
+
+	table := []struct {
+		name   string
+		exists bool
+		flag   int
+	}{
+		{"read only", true, os.O_RDONLY},
+		{"write existing", true, os.O_WRONLY},
+		{"write missing", false, os.O_WRONLY},
+		{"create missing", false, os.O_WRONLY | os.O_CREATE},
+		{"create existing", true, os.O_WRONLY | os.O_CREATE},
+		{"append", true, os.O_WRONLY | os.O_APPEND},
+		{"append create missing", false, os.O_WRONLY | os.O_APPEND | os.O_CREATE},
+		{"truncate", true, os.O_WRONLY | os.O_TRUNC},
+		{"read write truncate", true, os.O_RDWR | os.O_TRUNC},
+		{"exclusive missing", false, os.O_WRONLY | os.O_CREATE | os.O_EXCL},
+		{"exclusive existing", true, os.O_WRONLY | os.O_CREATE | os.O_EXCL},
+	}
+
+	//	run the same sequence against os and against a MemFS, reporting what happened
+	type outcome struct {
+		openErr  error
+		writeErr error
+		content  string
+	}
+	exerciseOS := func(name string, exists bool, flag int) (o outcome) {
+		if exists {
+			os.WriteFile(name, []byte("hello"), 0644)
+		}
+		f, err := os.OpenFile(name, flag, 0644)
+		if err != nil {
+			return outcome{openErr: err}
+		}
+		_, o.writeErr = f.Write([]byte("XY"))
+		f.Close()
+		data, _ := os.ReadFile(name)
+		o.content = string(data)
+		return o
+	}
+	exerciseMem := func(name string, exists bool, flag int) (o outcome) {
+		m := flargs.NewMemFS()
+		if exists {
+			m.WriteFile(name, []byte("hello"), 0644)
+		}
+		f, err := m.OpenFile(name, flag, 0644)
+		if err != nil {
+			return outcome{openErr: err}
+		}
+		_, o.writeErr = f.Write([]byte("XY"))
+		f.Close()
+		data, _ := m.ReadFile(name)
+		o.content = string(data)
+		return o
+	}
+
+	for _, row := range table {
+		t.Run(row.name, func(t *testing.T) {
+			want := exerciseOS(filepath.Join(t.TempDir(), "file.txt"), row.exists, row.flag)
+			got := exerciseMem("file.txt", row.exists, row.flag)
+
+			if (got.openErr == nil) != (want.openErr == nil) {
+				t.Fatalf("got open error %v but os gave %v", got.openErr, want.openErr)
+			}
+			if want.openErr != nil {
+				for _, target := range []error{fs.ErrExist, fs.ErrNotExist} {
+					if errors.Is(want.openErr, target) != errors.Is(got.openErr, target) {
+						t.Errorf("got open error %v but os gave %v", got.openErr, want.openErr)
+					}
+				}
+				return
+			}
+			if (got.writeErr == nil) != (want.writeErr == nil) {
+				t.Errorf("got write error %v but os gave %v", got.writeErr, want.writeErr)
+			}
+			if got.content != want.content {
+				t.Errorf("got content %q but os gave %q", got.content, want.content)
+			}
+		})
+	}
+
+}
+
+func TestMemFS_WalkDir(t *testing.T) {
+
+	m := flargs.NewMemFS()
+	m.WriteFile("a.txt", []byte("a"), 0644)
+	m.WriteFile("dir/b.txt", []byte("bb"), 0644)
+	m.WriteFile("/dir/sub/c.txt", []byte("ccc"), 0600)
+
+	var got []string
+	err := fs.WalkDir(m, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		got = append(got, p)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{".", "a.txt", "dir", "dir/b.txt", "dir/sub", "dir/sub/c.txt"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestMemFS_modTime(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	epoch := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	env.SetClock(&tickingClock{now: epoch})
+
+	if err := env.Filesystem.WriteFile("stamp.txt", []byte("now"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := env.Filesystem.Stat("stamp.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := epoch.Add(time.Second)
+	if got := info.ModTime(); !got.Equal(want) {
+		t.Errorf("got modtime %s but wanted %s", got, want)
+	}
+
+	f, _ := env.Filesystem.OpenFile("stamp.txt", os.O_WRONLY|os.O_APPEND, 0)
+	f.Write([]byte("!"))
+	f.Close()
+	info, _ = env.Filesystem.Stat("stamp.txt")
+	want = epoch.Add(2 * time.Second)
+	if got := info.ModTime(); !got.Equal(want) {
+		t.Errorf("got modtime %s after append but wanted %s", got, want)
+	}
+
+}
+
+func TestMemFS_zeroValue(t *testing.T) {
+
+	var m flargs.MemFS
+	if err := m.WriteFile("a.txt", []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := m.OpenFile("b.txt", os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if got, err := m.ReadFile("a.txt"); err != nil || string(got) != "a" {
+		t.Errorf("got %q, %v but wanted %q", got, err, "a")
+	}
+
+}
+
+func TestMemFS_fileOrDirectory(t *testing.T) {
+
+	m := flargs.NewMemFS()
+	m.WriteFile("dir/file.txt", []byte("x"), 0644)
+	m.WriteFile("plain.txt", []byte("y"), 0644)
+
+	for name, err := range map[string]error{
+		"WriteFile over a directory":  m.WriteFile("dir", nil, 0644),
+		"WriteFile under a file":      m.WriteFile("plain.txt/child", nil, 0644),
+		"OpenFile under a file":       openErr(m.OpenFile("plain.txt/child", os.O_WRONLY|os.O_CREATE, 0644)),
+		"OpenFile under a file, deep": openErr(m.OpenFile("plain.txt/a/b", os.O_WRONLY|os.O_CREATE, 0644)),
+		"Rename over a directory":     m.Rename("plain.txt", "dir"),
+		"Rename under a file":         m.Rename("dir/file.txt", "plain.txt/file.txt"),
+	} {
+		if !errors.Is(err, fs.ErrExist) {
+			t.Errorf("%s: got %v but wanted fs.ErrExist", name, err)
+		}
+	}
+	if info, err := m.Stat("dir"); err != nil || !info.IsDir() {
+		t.Errorf("got %v, %v but wanted dir to still be a directory", info, err)
+	}
+
+}
+
+// openErr is the error from an OpenFile, closing the file if there is one
+func openErr(f interface{ Close() error }, err error) error {
+	if err == nil {
+		f.Close()
+	}
+	return err
+}
@@ -0,0 +1,138 @@
+package flargs
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	rfs "github.com/sean9999/go-real-fs"
+)
+
+// Metrics accumulates simple counters and timers for a command.
+// It is safe for concurrent use.
+type Metrics struct {
+	mu       sync.Mutex
+	clock    Clock
+	fs       rfs.WritableFs
+	mode     fs.FileMode
+	wd       string
+	counters map[string]int
+	timers   map[string][]time.Duration
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{
+		clock:    SystemClock{},
+		counters: map[string]int{},
+		timers:   map[string][]time.Duration{},
+	}
+}
+
+// Metrics returns the Environment's [Metrics], timed by its [Clock] and flushed to its Filesystem.
+// It is created on first use if no constructor set it up, and shared by copies of the Environment made after that.
+func (e *Environment) Metrics() *Metrics {
+	lazyMu.Lock()
+	if e.metrics == nil {
+		e.metrics = newMetrics()
+	}
+	m := e.metrics
+	lazyMu.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if e.Clock != nil {
+		m.clock = e.Clock
+	}
+	m.fs = e.Filesystem
+	m.mode = e.FileMode()
+	m.wd = e.WorkingDir
+	return m
+}
+
+// Count adds n to the counter called name
+func (m *Metrics) Count(name string, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name] += n
+}
+
+// Timer starts timing name. Call the returned function to record the elapsed time.
+func (m *Metrics) Timer(name string) func() {
+	m.mu.Lock()
+	clock := m.clock
+	m.mu.Unlock()
+	start := clock.Now()
+	return func() {
+		elapsed := clock.Now().Sub(start)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.timers[name] = append(m.timers[name], elapsed)
+	}
+}
+
+type timerSummary struct {
+	Count int           `json:"count"`
+	Total time.Duration `json:"total"`
+}
+
+type metricsDocument struct {
+	Counters map[string]int          `json:"counters"`
+	Timers   map[string]timerSummary `json:"timers"`
+}
+
+// MarshalJSON encodes counters, and timers as a count and total duration in nanoseconds
+func (m *Metrics) MarshalJSON() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	doc := metricsDocument{
+		Counters: make(map[string]int, len(m.counters)),
+		Timers:   make(map[string]timerSummary, len(m.timers)),
+	}
+	for k, v := range m.counters {
+		doc.Counters[k] = v
+	}
+	for k, samples := range m.timers {
+		var total time.Duration
+		for _, d := range samples {
+			total += d
+		}
+		doc.Timers[k] = timerSummary{len(samples), total}
+	}
+	return json.Marshal(doc)
+}
+
+// Flush writes the accumulated metrics as JSON to path on the Environment's Filesystem.
+// A relative path is resolved against the Environment's WorkingDir.
+func (m *Metrics) Flush(path string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	fsys, mode, wd := m.fs, m.mode, m.wd
+	m.mu.Unlock()
+	if fsys == nil {
+		return errors.New("metrics: no filesystem to flush to")
+	}
+	return fsys.WriteFile(resolvePath(wd, path), data, mode)
+}
+
+// TimerStats summarizes the samples recorded by the timer called name:
+// how many there are, the 50th and 95th percentiles by nearest rank, and the longest.
+// All are zero if nothing has been recorded.
+func (m *Metrics) TimerStats(name string) (count int, p50, p95, max time.Duration) {
+	m.mu.Lock()
+	samples := slices.Clone(m.timers[name])
+	m.mu.Unlock()
+	if len(samples) == 0 {
+		return 0, 0, 0, 0
+	}
+	slices.Sort(samples)
+	rank := func(p float64) time.Duration {
+		return samples[int(math.Ceil(p*float64(len(samples))))-1]
+	}
+	return len(samples), rank(0.50), rank(0.95), samples[len(samples)-1]
+}
@@ -0,0 +1,113 @@
+package flargs_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+	realfs "github.com/sean9999/go-real-fs"
+)
+
+func TestMetrics_Flush(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem = realfs.NewTestFs()
+	env.Clock = &tickingClock{}
+
+	m := env.Metrics()
+	m.Count("files", 2)
+	env.Metrics().Count("files", 1)
+	stop := m.Timer("walk")
+	stop()
+
+	if err := m.Flush("metrics.json"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := env.Filesystem.ReadFile(env.ResolvePath("metrics.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got struct {
+		Counters map[string]int `json:"counters"`
+		Timers   map[string]struct {
+			Count int   `json:"count"`
+			Total int64 `json:"total"`
+		} `json:"timers"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Counters["files"] != 3 {
+		t.Errorf("got %d files but wanted 3", got.Counters["files"])
+	}
+	if got.Timers["walk"].Count != 1 || got.Timers["walk"].Total != 1e9 {
+		t.Errorf("got walk timer %+v but wanted one sample totalling 1s", got.Timers["walk"])
+	}
+
+}
+
+// stepClock moves forward by each of steps in turn, one per call to Now
+type stepClock struct {
+	now   time.Time
+	steps []time.Duration
+}
+
+func (c *stepClock) Now() time.Time {
+	t := c.now
+	if len(c.steps) > 0 {
+		c.now = c.now.Add(c.steps[0])
+		c.steps = c.steps[1:]
+	}
+	return t
+}
+
+func (c *stepClock) After(time.Duration) <-chan time.Time {
+	return nil
+}
+
+func TestMetrics_TimerStats(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	clock := &stepClock{}
+	//	samples of 20ms, 19ms ... 1ms, each followed by a pause between timers
+	for d := 20; d >= 1; d-- {
+		clock.steps = append(clock.steps, time.Duration(d)*time.Millisecond, time.Second)
+	}
+	env.Clock = clock
+	m := env.Metrics()
+	for range 20 {
+		m.Timer("fetch")()
+	}
+
+	count, p50, p95, max := m.TimerStats("fetch")
+	if count != 20 {
+		t.Errorf("got %d samples but wanted 20", count)
+	}
+	if p50 != 10*time.Millisecond || p95 != 19*time.Millisecond || max != 20*time.Millisecond {
+		t.Errorf("got p50=%s p95=%s max=%s but wanted 10ms, 19ms and 20ms", p50, p95, max)
+	}
+	if count, _, _, _ := m.TimerStats("missing"); count != 0 {
+		t.Errorf("got %d samples for a timer never used", count)
+	}
+
+}
+
+func TestEnvironment_Metrics_handBuilt(t *testing.T) {
+
+	env := &flargs.Environment{Filesystem: flargs.NewMemFS(), WorkingDir: "/var/run"}
+	env.Metrics().Count("runs", 1)
+	env.Metrics().Count("runs", 1)
+	if err := env.Metrics().Flush("metrics.json"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := env.Filesystem.ReadFile("/var/run/metrics.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"counters":{"runs":2},"timers":{}}`; string(data) != want {
+		t.Errorf("got %q but wanted %q", data, want)
+	}
+
+}
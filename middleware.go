@@ -0,0 +1,104 @@
+package flargs
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+)
+
+// a Middleware wraps a [Command] with cross-cutting behaviour such as logging or timing
+type Middleware func(Command) Command
+
+// Chain wraps c with mw. The first Middleware is the outermost,
+// so it runs first and finishes last.
+func Chain(c Command, mw ...Middleware) Command {
+	for i := len(mw) - 1; i >= 0; i-- {
+		c = mw[i](c)
+	}
+	return c
+}
+
+// WrapRun returns a copy of c whose Run is replaced by fn.
+// fn receives the original [Flarger] so it can decide when, or whether, to run it.
+func WrapRun(c Command, fn func(next Flarger, env *Environment) error) Command {
+	return Command{wrappedFlarger{c.Flarger, fn}, c.Environment}
+}
+
+type wrappedFlarger struct {
+	Flarger
+	run func(Flarger, *Environment) error
+}
+
+func (w wrappedFlarger) Run(env *Environment) error {
+	return w.run(w.Flarger, env)
+}
+
+// TimingMiddleware writes how long Run took to the ErrorStream, as measured by the [Clock],
+// or by the wall clock if the Environment has none
+func TimingMiddleware(c Command) Command {
+	return WrapRun(c, func(next Flarger, env *Environment) error {
+		clock := env.Clock
+		if clock == nil {
+			clock = SystemClock{}
+		}
+		start := clock.Now()
+		err := next.Run(env)
+		fmt.Fprintf(env.ErrorStream, "flargs: ran in %s\n", clock.Now().Sub(start))
+		return err
+	})
+}
+
+// LoggingMiddleware writes a line to the ErrorStream before and after Run,
+// naming the command by the base of Arguments[0], or by its type if there are no Arguments
+func LoggingMiddleware(c Command) Command {
+	return WrapRun(c, func(next Flarger, env *Environment) error {
+		name := commandName(next, env)
+		fmt.Fprintf(env.ErrorStream, "flargs: running %s\n", name)
+		err := next.Run(env)
+		if err != nil {
+			fmt.Fprintf(env.ErrorStream, "flargs: %s failed: %s\n", name, err)
+		} else {
+			fmt.Fprintf(env.ErrorStream, "flargs: %s finished\n", name)
+		}
+		return err
+	})
+}
+
+// commandName is what [LoggingMiddleware] calls next
+func commandName(next Flarger, env *Environment) string {
+	if len(env.Arguments) == 0 || env.Arguments[0] == "" {
+		return fmt.Sprintf("%T", next)
+	}
+	return path.Base(cleanPath(env.Arguments[0]))
+}
+
+// WithTimeout gives c at most d to Run, as measured by the [Clock].
+// c runs with a Context derived from the Environment's, which is cancelled, with [context.DeadlineExceeded] as its cause,
+// as soon as time runs out, and in any case once WithTimeout returns.
+// If it takes longer, onTimeout (when not nil) is called, a message goes to the ErrorStream,
+// and Run returns [ExitCodeTimeout] without waiting. If the Environment's own Context is done first, its error is returned.
+// The abandoned Run may finish later; its result is discarded rather than leaking a blocked goroutine.
+func WithTimeout(c Command, d time.Duration, onTimeout func(*Environment)) Command {
+	return WrapRun(c, func(next Flarger, env *Environment) error {
+		ctx, cancel := context.WithCancelCause(env.ctxOr(nil))
+		defer cancel(nil)
+		done := make(chan error, 1)
+		go func() {
+			done <- next.Run(env.WithContext(ctx))
+		}()
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-env.Clock.After(d):
+			cancel(context.DeadlineExceeded)
+			if onTimeout != nil {
+				onTimeout(env)
+			}
+			fmt.Fprintf(env.ErrorStream, "flargs: timed out after %s\n", d)
+			return ExitCodeTimeout
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package flargs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+// tickingClock advances one second every time it's asked the time
+type tickingClock struct {
+	now time.Time
+}
+
+func (c *tickingClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func (c *tickingClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func tagged(tag string) flargs.Middleware {
+	return func(c flargs.Command) flargs.Command {
+		return flargs.WrapRun(c, func(next flargs.Flarger, env *flargs.Environment) error {
+			fmt.Fprintf(env.OutputStream, "%s(", tag)
+			err := next.Run(env)
+			fmt.Fprintf(env.OutputStream, ")%s", tag)
+			return err
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+
+	t.Run("ordering", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		inner := flargs.CommandFunc(func(e *flargs.Environment) error {
+			fmt.Fprint(e.OutputStream, "run")
+			return nil
+		})
+		inner.Environment = env
+		cmd := flargs.Chain(inner, tagged("a"), tagged("b"))
+		if code := cmd.Execute(nil); code != flargs.ExitCodeSuccess {
+			t.Fatalf("got exit code %d", code)
+		}
+		want := "a(b(run)b)a"
+		got := string(env.GetOutput())
+		if got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+	})
+
+	t.Run("timing", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Clock = &tickingClock{}
+		cmd := flargs.Chain(flargs.NewCommand(new(flargs.StateMachine), env), flargs.TimingMiddleware)
+		cmd.Execute(nil)
+		want := "flargs: ran in 1s\n"
+		got := string(env.GetError())
+		if got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+	})
+
+	t.Run("timing without a clock", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Clock = nil
+		cmd := flargs.Chain(flargs.NewCommand(new(flargs.StateMachine), env), flargs.TimingMiddleware)
+		if code := cmd.Execute(nil); code != flargs.ExitCodeSuccess {
+			t.Fatalf("got exit code %d", code)
+		}
+		if got := string(env.GetError()); !strings.HasPrefix(got, "flargs: ran in ") {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("logging", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Arguments = []string{"/usr/local/bin/kat", "a.txt"}
+		failing := flargs.CommandFunc(func(*flargs.Environment) error {
+			return errors.New("no cats")
+		})
+		failing.Environment = env
+		flargs.Chain(failing, flargs.LoggingMiddleware).Execute(nil)
+		want := "flargs: running kat\nflargs: kat failed: no cats\nerror: no cats\n"
+		if got := string(env.GetError()); got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+	})
+
+}
+
+func TestWithTimeout(t *testing.T) {
+
+	env := flargs.NewConcurrentTestingEnvironment(nil)
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	slow := flargs.CommandFunc(func(e *flargs.Environment) error {
+		<-release
+		close(finished)
+		return nil
+	})
+	slow.Environment = env
+
+	cleanedUp := false
+	cmd := flargs.WithTimeout(slow, 10*time.Millisecond, func(e *flargs.Environment) {
+		cleanedUp = true
+	})
+	if code := cmd.Execute(nil); code != flargs.ExitCodeTimeout {
+		t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeTimeout)
+	}
+	if !cleanedUp {
+		t.Error("onTimeout was not called")
+	}
+	if got, want := string(env.GetError()), "flargs: timed out after 10ms\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+	//	the abandoned command can still finish
+	close(release)
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Error("abandoned command never finished")
+	}
+
+}
+
+func TestWithTimeout_cancels(t *testing.T) {
+
+	env := flargs.NewConcurrentTestingEnvironment(nil)
+	cause := make(chan error, 1)
+	patient := flargs.CommandFunc(func(e *flargs.Environment) error {
+		<-e.Context.Done()
+		cause <- context.Cause(e.Context)
+		return e.Context.Err()
+	})
+	patient.Environment = env
+	if code := flargs.WithTimeout(patient, 10*time.Millisecond, nil).Execute(nil); code != flargs.ExitCodeTimeout {
+		t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeTimeout)
+	}
+	select {
+	case err := <-cause:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got %v but wanted %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the abandoned command was never told to stop")
+	}
+
+	t.Run("carried context", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		env.Context = ctx
+		patient.Environment = env
+		if code := flargs.WithTimeout(patient, time.Hour, nil).Execute(nil); code != flargs.ExitCodeGenericError {
+			t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeGenericError)
+		}
+	})
+
+}
+
+func TestWithTimeout_fast(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fast := flargs.CommandFunc(func(e *flargs.Environment) error {
+		return nil
+	})
+	fast.Environment = env
+	cmd := flargs.WithTimeout(fast, time.Minute, nil)
+	if code := cmd.Execute(nil); code != flargs.ExitCodeSuccess {
+		t.Errorf("got exit code %d", code)
+	}
+
+}
+
+func TestWithTimeout_deterministic(t *testing.T) {
+
+	env := flargs.NewDeterministicEnvironment(0)
+	patient := flargs.CommandFunc(func(e *flargs.Environment) error {
+		<-e.Context.Done()
+		return e.Context.Err()
+	})
+	patient.Environment = env
+	done := make(chan flargs.ExitCode, 1)
+	go func() {
+		done <- flargs.WithTimeout(patient, time.Hour, nil).Execute(nil)
+	}()
+	select {
+	case code := <-done:
+		if code != flargs.ExitCodeTimeout {
+			t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeTimeout)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WithTimeout hung under a FixedClock")
+	}
+
+}
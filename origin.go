@@ -0,0 +1,61 @@
+package flargs
+
+// an Origin is where a variable's value came from
+type Origin uint8
+
+const (
+	// OriginSet is a variable set by the program itself
+	OriginSet Origin = iota
+	// OriginInherited is a variable inherited from the process environment
+	OriginInherited
+	// OriginFile is a variable loaded from a file, by [Environment.LoadConfig] or [Environment.ResolveFileVars]
+	OriginFile
+)
+
+func (o Origin) String() string {
+	switch o {
+	case OriginInherited:
+		return "inherited"
+	case OriginFile:
+		return "file"
+	default:
+		return "set"
+	}
+}
+
+// tagVar records where the variable key came from
+func (e *Environment) tagVar(key string, o Origin) {
+	if e.origins == nil {
+		e.origins = map[string]Origin{}
+	}
+	e.origins[key] = o
+}
+
+// SetVar sets the variable key, and records it as [OriginSet]
+func (e *Environment) SetVar(key, value string) {
+	if e.Variables == nil {
+		e.Variables = map[string]string{}
+	}
+	e.Variables[key] = value
+	e.tagVar(key, OriginSet)
+}
+
+// VarOrigin reports where the variable key came from, and false if it isn't set.
+// A variable written straight into Variables counts as [OriginSet],
+// including an inherited or loaded one whose value has since been changed that way.
+func (e Environment) VarOrigin(key string) (Origin, bool) {
+	v, exists := e.Variables[key]
+	if !exists {
+		return 0, false
+	}
+	o, tagged := e.origins[key]
+	if !tagged {
+		return OriginSet, true
+	}
+	if o == OriginInherited {
+		if base, inherited := e.baseVars[key]; !inherited || base != v {
+			return OriginSet, true
+		}
+	}
+	return o, true
+}
@@ -0,0 +1,35 @@
+package flargs
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+)
+
+// OutputFormat returns the requested output format, from an "-o" or "--output" argument,
+// or else the FLARGS_OUTPUT variable. A format not in allowed is ignored.
+// If nothing valid was requested, it returns def.
+func (e Environment) OutputFormat(def string, allowed ...string) string {
+	if format, found := e.flagValue("-o", "--output"); found && slices.Contains(allowed, format) {
+		return format
+	}
+	if format := e.Variables["FLARGS_OUTPUT"]; slices.Contains(allowed, format) {
+		return format
+	}
+	return def
+}
+
+// Emit encodes v to OutputStream in format.
+// "json" is indented JSON and "text" is v's default formatting. Other formats are an error.
+func (e Environment) Emit(v any, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(e.OutputStream)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case "text":
+		_, err := fmt.Fprintln(e.OutputStream, v)
+		return err
+	}
+	return fmt.Errorf("unsupported output format: %q", format)
+}
@@ -0,0 +1,56 @@
+package flargs_test
+
+import (
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_OutputFormat(t *testing.T) {
+
+	allowed := []string{"json", "yaml", "table"}
+	table := []struct {
+		name string
+		args []string
+		vars map[string]string
+		want string
+	}{
+		{"default", nil, nil, "table"},
+		{"short flag", []string{"-o", "json"}, nil, "json"},
+		{"long flag with equals", []string{"--output=yaml"}, nil, "yaml"},
+		{"variable", nil, map[string]string{"FLARGS_OUTPUT": "json"}, "json"},
+		{"flag beats variable", []string{"-o", "yaml"}, map[string]string{"FLARGS_OUTPUT": "json"}, "yaml"},
+		{"invalid flag", []string{"-o", "xml"}, nil, "table"},
+		{"invalid variable", nil, map[string]string{"FLARGS_OUTPUT": "xml"}, "table"},
+		{"after terminator", []string{"--", "-o", "json"}, nil, "table"},
+	}
+
+	for _, row := range table {
+		t.Run(row.name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			env.Arguments = append([]string{"prog"}, row.args...)
+			for k, v := range row.vars {
+				env.Variables[k] = v
+			}
+			if got := env.OutputFormat("table", allowed...); got != row.want {
+				t.Errorf("got %q but wanted %q", got, row.want)
+			}
+		})
+	}
+
+}
+
+func TestEnvironment_Emit(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	if err := env.Emit(map[string]int{"files": 2}, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(env.GetOutput()), "{\n  \"files\": 2\n}\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if err := env.Emit(1, "xml"); err == nil {
+		t.Error("wanted an error for an unsupported format")
+	}
+
+}
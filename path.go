@@ -0,0 +1,68 @@
+package flargs
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// CleanPath converts p to the forward-slash form expected by [io/fs],
+// regardless of GOOS. Backslashes become slashes and the result is cleaned,
+// so paths built with [path/filepath.Join] on Windows still match keys in a [testing/fstest.MapFS].
+func (e Environment) CleanPath(p string) string {
+	return cleanPath(p)
+}
+
+func cleanPath(p string) string {
+	return path.Clean(strings.ReplaceAll(p, `\`, "/"))
+}
+
+// ResolvePath makes p absolute by joining it to WorkingDir, unless it already is.
+// The result is cleaned as by [Environment.CleanPath].
+// Windows paths count as absolute when they start with a drive, as in C:\data, or are UNC paths, as in \\server\share,
+// which keep their leading pair of slashes.
+func (e Environment) ResolvePath(p string) string {
+	return resolvePath(e.WorkingDir, p)
+}
+
+func resolvePath(wd, p string) string {
+	if isUNC(p) {
+		return "/" + cleanPath(p)
+	}
+	p = cleanPath(p)
+	if path.IsAbs(p) || hasDrive(p) || wd == "" {
+		return p
+	}
+	return path.Join(cleanPath(wd), p)
+}
+
+// isUNC reports whether p is a Windows UNC path, like \\server\share, in either kind of slash
+func isUNC(p string) bool {
+	p = strings.ReplaceAll(p, `\`, "/")
+	return len(p) > 2 && strings.HasPrefix(p, "//") && p[2] != '/'
+}
+
+// hasDrive reports whether the cleaned path p starts with a drive, like C: or C:/data
+func hasDrive(p string) bool {
+	if len(p) < 2 || p[1] != ':' || (len(p) > 2 && p[2] != '/') {
+		return false
+	}
+	c := p[0] | 0x20
+	return c >= 'a' && c <= 'z'
+}
+
+// Chdir changes WorkingDir. Relative paths are resolved against the current WorkingDir.
+// It is an error if dir is not a directory on the Filesystem.
+func (e *Environment) Chdir(dir string) error {
+	target := e.ResolvePath(dir)
+	info, err := e.Filesystem.Stat(target)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: "chdir", Path: dir, Err: errors.New("not a directory")}
+	}
+	e.WorkingDir = target
+	return nil
+}
@@ -0,0 +1,96 @@
+package flargs_test
+
+import (
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_CleanPath(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+
+	table := map[string]string{
+		`dir\sub\file.txt`:  "dir/sub/file.txt",
+		`.\a\..\b`:          "b",
+		`mixed/style\path`:  "mixed/style/path",
+		`already/clean.txt`: "already/clean.txt",
+		`trailing\slash\`:   "trailing/slash",
+		``:                  ".",
+	}
+
+	for input, want := range table {
+		got := env.CleanPath(input)
+		if got != want {
+			t.Errorf("CleanPath(%q): got %q but wanted %q", input, got, want)
+		}
+	}
+
+}
+
+func TestEnvironment_ResolvePath(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.WorkingDir = "/home/robin"
+
+	table := map[string]string{
+		"notes.txt":      "/home/robin/notes.txt",
+		"../sam/a.txt":   "/home/sam/a.txt",
+		"/etc/passwd":    "/etc/passwd",
+		`docs\readme.md`: "/home/robin/docs/readme.md",
+	}
+	for input, want := range table {
+		if got := env.ResolvePath(input); got != want {
+			t.Errorf("ResolvePath(%q): got %q but wanted %q", input, got, want)
+		}
+	}
+
+	env.WorkingDir = `C:\Users\me`
+	for input, want := range map[string]string{
+		`C:\data\in.txt`:        "C:/data/in.txt",
+		`d:/data/in.txt`:        "d:/data/in.txt",
+		`C:`:                    "C:",
+		`data\in.txt`:           "C:/Users/me/data/in.txt",
+		`..\you\in.txt`:         "C:/Users/you/in.txt",
+		`\\server\share\in.txt`: "//server/share/in.txt",
+		`//server/share/in.txt`: "//server/share/in.txt",
+		`CD:\in.txt`:            `C:/Users/me/CD:/in.txt`,
+	} {
+		if got := env.ResolvePath(input); got != want {
+			t.Errorf("ResolvePath(%q) in %s: got %q but wanted %q", input, env.WorkingDir, got, want)
+		}
+	}
+
+}
+
+func TestEnvironment_Chdir(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem.WriteFile("/projects/flargs/go.mod", []byte("module flargs"), 0644)
+
+	if env.WorkingDir != "/" {
+		t.Fatalf("got working dir %q but wanted /", env.WorkingDir)
+	}
+	if err := env.Chdir("projects"); err != nil {
+		t.Fatal(err)
+	}
+	if err := env.Chdir("flargs"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := env.WorkingDir, "/projects/flargs"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if got, want := env.ResolvePath("go.mod"), "/projects/flargs/go.mod"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if err := env.Chdir("go.mod"); err == nil {
+		t.Error("chdir into a file should fail")
+	}
+	if err := env.Chdir("/nowhere"); err == nil {
+		t.Error("chdir into a missing directory should fail")
+	}
+	if got, want := env.WorkingDir, "/projects/flargs"; got != want {
+		t.Errorf("failed chdir changed working dir to %q", got)
+	}
+
+}
@@ -0,0 +1,33 @@
+package flargs
+
+import "fmt"
+
+// Print writes to OutputStream, as [fmt.Print] writes to standard output
+func (e Environment) Print(a ...any) {
+	fmt.Fprint(e.OutputStream, a...)
+}
+
+// Printf writes to OutputStream, as [fmt.Printf] writes to standard output
+func (e Environment) Printf(format string, a ...any) {
+	fmt.Fprintf(e.OutputStream, format, a...)
+}
+
+// Println writes to OutputStream, as [fmt.Println] writes to standard output
+func (e Environment) Println(a ...any) {
+	fmt.Fprintln(e.OutputStream, a...)
+}
+
+// Eprint is [Environment.Print] for ErrorStream
+func (e Environment) Eprint(a ...any) {
+	fmt.Fprint(e.ErrorStream, a...)
+}
+
+// Eprintf is [Environment.Printf] for ErrorStream
+func (e Environment) Eprintf(format string, a ...any) {
+	fmt.Fprintf(e.ErrorStream, format, a...)
+}
+
+// Eprintln is [Environment.Println] for ErrorStream
+func (e Environment) Eprintln(a ...any) {
+	fmt.Fprintln(e.ErrorStream, a...)
+}
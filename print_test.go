@@ -0,0 +1,38 @@
+package flargs_test
+
+import (
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_Print(t *testing.T) {
+
+	table := []struct {
+		name    string
+		print   func(e *flargs.Environment)
+		wantOut string
+		wantErr string
+	}{
+		{"Print", func(e *flargs.Environment) { e.Print("a", 1, 2, "b") }, "a1 2b", ""},
+		{"Printf", func(e *flargs.Environment) { e.Printf("%03d", 7) }, "007", ""},
+		{"Println", func(e *flargs.Environment) { e.Println("a", 1) }, "a 1\n", ""},
+		{"Eprint", func(e *flargs.Environment) { e.Eprint("a", 1, 2, "b") }, "", "a1 2b"},
+		{"Eprintf", func(e *flargs.Environment) { e.Eprintf("%03d", 7) }, "", "007"},
+		{"Eprintln", func(e *flargs.Environment) { e.Eprintln("a", 1) }, "", "a 1\n"},
+	}
+
+	for _, row := range table {
+		t.Run(row.name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			row.print(env)
+			if got := env.OutputString(); got != row.wantOut {
+				t.Errorf("got output %q but wanted %q", got, row.wantOut)
+			}
+			if got := string(env.GetError()); got != row.wantErr {
+				t.Errorf("got errors %q but wanted %q", got, row.wantErr)
+			}
+		})
+	}
+
+}
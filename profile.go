@@ -0,0 +1,53 @@
+package flargs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+)
+
+// MaybeProfile starts CPU and/or heap profiling when FLARGS_CPUPROFILE or FLARGS_MEMPROFILE are set.
+// Profiles are written to those paths on the Filesystem when stop is called, and any failure to write them
+// is reported to ErrorStream. If neither variable is set, stop is a no-op.
+// Relative paths are resolved against WorkingDir as it is when MaybeProfile is called.
+func (e *Environment) MaybeProfile() (stop func(), err error) {
+	cpuPath := e.Variables["FLARGS_CPUPROFILE"]
+	memPath := e.Variables["FLARGS_MEMPROFILE"]
+	if cpuPath != "" {
+		cpuPath = e.ResolvePath(cpuPath)
+	}
+	if memPath != "" {
+		memPath = e.ResolvePath(memPath)
+	}
+
+	var cpuBuf *bytes.Buffer
+	if cpuPath != "" {
+		cpuBuf = new(bytes.Buffer)
+		if err := pprof.StartCPUProfile(cpuBuf); err != nil {
+			return func() {}, err
+		}
+	}
+
+	stop = func() {
+		var errs []error
+		if cpuBuf != nil {
+			pprof.StopCPUProfile()
+			errs = append(errs, e.Filesystem.WriteFile(cpuPath, cpuBuf.Bytes(), e.FileMode()))
+		}
+		if memPath != "" {
+			memBuf := new(bytes.Buffer)
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(memBuf); err != nil {
+				errs = append(errs, err)
+			} else {
+				errs = append(errs, e.Filesystem.WriteFile(memPath, memBuf.Bytes(), e.FileMode()))
+			}
+		}
+		if err := errors.Join(errs...); err != nil {
+			fmt.Fprintln(e.ErrorStream, e.FormatError(err))
+		}
+	}
+	return stop, nil
+}
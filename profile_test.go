@@ -0,0 +1,38 @@
+package flargs_test
+
+import (
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+	realfs "github.com/sean9999/go-real-fs"
+)
+
+func TestEnvironment_MaybeProfile(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem = realfs.NewTestFs()
+	env.WorkingDir = "/tmp/run"
+	env.Variables["FLARGS_CPUPROFILE"] = "cpu.pprof"
+	env.Variables["FLARGS_MEMPROFILE"] = "mem.pprof"
+
+	stop, err := env.MaybeProfile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.WorkingDir = "/"
+	stop()
+	if got := string(env.GetError()); got != "" {
+		t.Fatalf("got stderr %q", got)
+	}
+
+	for _, name := range []string{"/tmp/run/cpu.pprof", "/tmp/run/mem.pprof"} {
+		data, err := env.Filesystem.ReadFile(name)
+		if err != nil {
+			t.Error(err)
+		}
+		if len(data) == 0 {
+			t.Errorf("%s is empty", name)
+		}
+	}
+
+}
@@ -0,0 +1,160 @@
+package flargs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrPromptTimeout is returned by [Environment.PromptWithTimeout] when no answer arrives in time and there is no default
+var ErrPromptTimeout = errors.New("timed out waiting for input")
+
+// readLine reads one line from r, one byte at a time, so nothing past the newline is consumed
+func readLine(r io.Reader) (string, error) {
+	var sb strings.Builder
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n > 0 {
+			if b[0] == '\n' {
+				return strings.TrimSuffix(sb.String(), "\r"), nil
+			}
+			sb.WriteByte(b[0])
+		}
+		if err != nil {
+			if err == io.EOF && sb.Len() > 0 {
+				return sb.String(), nil
+			}
+			return sb.String(), err
+		}
+	}
+}
+
+// lineResult is one line read by a [lineReader]
+type lineResult struct {
+	answer string
+	err    error
+}
+
+// lineReader reads the answers to prompts.
+// A read abandoned by [Environment.PromptWithTimeout] is kept, and the next prompt on the same stream takes its line,
+// so a late answer is never lost to a goroutine nobody is waiting on.
+type lineReader struct {
+	mu      sync.Mutex
+	pending chan lineResult
+	from    io.Reader
+}
+
+// sameStream reports whether a and b are the same stream, without panicking on streams that can't be compared
+func sameStream(a, b io.Reader) bool {
+	if a == nil || b == nil || reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	return a == b
+}
+
+// read returns a channel that yields the next line of r, picking up an abandoned read of r if there is one
+func (l *lineReader) read(r io.Reader) chan lineResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ch := l.pending; ch != nil && sameStream(l.from, r) {
+		l.pending, l.from = nil, nil
+		return ch
+	}
+	ch := make(chan lineResult, 1)
+	go func() {
+		answer, err := readLine(r)
+		ch <- lineResult{answer, err}
+	}()
+	return ch
+}
+
+// abandon keeps ch, a read of r nobody is waiting on any more, for the next prompt
+func (l *lineReader) abandon(r io.Reader, ch chan lineResult) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending, l.from = ch, r
+}
+
+// promptReader returns the Environment's lineReader.
+// Without one, as in a hand-built Environment, each prompt reads on its own and an answer that comes after a timeout is lost.
+func (e Environment) promptReader() *lineReader {
+	if e.lines == nil {
+		return new(lineReader)
+	}
+	return e.lines
+}
+
+// ask writes question, and def if there is one, to OutputStream
+func (e Environment) ask(question, def string) {
+	if def != "" {
+		fmt.Fprintf(e.OutputStream, "%s [%s] ", question, def)
+	} else {
+		fmt.Fprintf(e.OutputStream, "%s ", question)
+	}
+}
+
+// answerOr turns a line read in answer to a prompt into the answer, or def
+func answerOr(r lineResult, def string) (string, error) {
+	if r.err != nil && r.err != io.EOF {
+		return def, r.err
+	}
+	answer := strings.TrimSpace(r.answer)
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// Prompt writes question to OutputStream and reads one line of answer from InputStream.
+// An empty answer, or no answer at all, yields def.
+func (e Environment) Prompt(question, def string) (string, error) {
+	e.ask(question, def)
+	return answerOr(<-e.promptReader().read(e.InputStream), def)
+}
+
+// Confirm asks a yes/no question. An empty answer yields def.
+func (e Environment) Confirm(question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := e.Prompt(question+" ("+hint+")", "")
+	if err != nil || answer == "" {
+		return def, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return def, fmt.Errorf("not a yes or no answer: %q", answer)
+}
+
+// PromptWithTimeout is [Environment.Prompt], but gives up after d, as measured by the [Clock].
+// On timeout it returns def, or [ErrPromptTimeout] if def is empty.
+// It also gives up with the context's error if the Environment's Context is done.
+// The read it gave up on is kept, and the next prompt on the same InputStream gets its line.
+func (e Environment) PromptWithTimeout(question, def string, d time.Duration) (string, error) {
+	e.ask(question, def)
+	lines := e.promptReader()
+	answered := lines.read(e.InputStream)
+	select {
+	case r := <-answered:
+		return answerOr(r, def)
+	case <-e.Clock.After(d):
+		lines.abandon(e.InputStream, answered)
+		if def == "" {
+			return "", ErrPromptTimeout
+		}
+		return def, nil
+	case <-e.ctxOr(nil).Done():
+		lines.abandon(e.InputStream, answered)
+		return def, e.ctxOr(nil).Err()
+	}
+}
@@ -0,0 +1,109 @@
+package flargs_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+// silentInput is an InputStream nobody ever types into
+type silentInput struct {
+	*io.PipeReader
+	io.Writer
+}
+
+func newSilentInput() (silentInput, func()) {
+	r, w := io.Pipe()
+	return silentInput{r, io.Discard}, func() { w.Close() }
+}
+
+func TestEnvironment_Prompt(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.InputStream.Write([]byte("robin\n\n"))
+
+	got, err := env.Prompt("name?", "world")
+	if err != nil || got != "robin" {
+		t.Errorf("got %q, %v but wanted robin", got, err)
+	}
+	got, err = env.Prompt("name?", "world")
+	if err != nil || got != "world" {
+		t.Errorf("got %q, %v but wanted the default", got, err)
+	}
+	if out := string(env.GetOutput()); out != "name? [world] name? [world] " {
+		t.Errorf("got prompt output %q", out)
+	}
+
+}
+
+func TestEnvironment_PromptWithTimeout(t *testing.T) {
+
+	t.Run("default", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		input, hangUp := newSilentInput()
+		defer hangUp()
+		env.InputStream = input
+		got, err := env.PromptWithTimeout("continue?", "yes", 10*time.Millisecond)
+		if err != nil || got != "yes" {
+			t.Errorf("got %q, %v but wanted the default", got, err)
+		}
+	})
+
+	t.Run("no default", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		input, hangUp := newSilentInput()
+		defer hangUp()
+		env.InputStream = input
+		_, err := env.PromptWithTimeout("name?", "", 10*time.Millisecond)
+		if !errors.Is(err, flargs.ErrPromptTimeout) {
+			t.Errorf("got %v but wanted ErrPromptTimeout", err)
+		}
+	})
+
+	t.Run("late answer goes to the next prompt", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		r, w := io.Pipe()
+		defer w.Close()
+		env.InputStream = silentInput{r, io.Discard}
+		if _, err := env.PromptWithTimeout("name?", "", 10*time.Millisecond); !errors.Is(err, flargs.ErrPromptTimeout) {
+			t.Fatalf("got %v but wanted ErrPromptTimeout", err)
+		}
+		go w.Write([]byte("robin\nbatman\n"))
+		for _, want := range []string{"robin", "batman"} {
+			got, err := env.Prompt("name?", "")
+			if err != nil || got != want {
+				t.Errorf("got %q, %v but wanted %q", got, err, want)
+			}
+		}
+	})
+
+	t.Run("answered in time", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write([]byte("no\n"))
+		got, err := env.PromptWithTimeout("continue?", "yes", time.Minute)
+		if err != nil || got != "no" {
+			t.Errorf("got %q, %v but wanted no", got, err)
+		}
+	})
+
+}
+
+func TestEnvironment_Confirm(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.InputStream.Write([]byte("y\n\nmaybe\n"))
+
+	if got, err := env.Confirm("sure?", false); err != nil || !got {
+		t.Errorf("got %v, %v but wanted true", got, err)
+	}
+	if got, err := env.Confirm("sure?", true); err != nil || !got {
+		t.Errorf("got %v, %v but wanted the default", got, err)
+	}
+	if _, err := env.Confirm("sure?", false); err == nil {
+		t.Error("wanted an error for an unrecognised answer")
+	}
+
+}
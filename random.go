@@ -0,0 +1,105 @@
+package flargs
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"slices"
+	"sync"
+)
+
+// fallbackSource is the seed-0 source an Environment without Randomness draws from, created on first use.
+// The constructors give each Environment its own, shared by its copies, so successive draws differ
+// as they would from any other source, and one Environment's draws don't shift another's.
+type fallbackSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (f *fallbackSource) Int63() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.src == nil {
+		f.src = rand.NewSource(0)
+	}
+	return f.src.Int63()
+}
+
+func (f *fallbackSource) Seed(seed int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.src = rand.NewSource(seed)
+}
+
+// handBuilt is the fallbackSource of Environments built by hand, which have no source of their own
+var handBuilt fallbackSource
+
+// rand draws from Randomness, falling back to the Environment's own source seeded with 0 when Randomness is nil,
+// as it is in a [NewNullEnvironment]. Environments built by hand have none, and share one between them.
+func (e Environment) rand() *rand.Rand {
+	if e.Randomness != nil {
+		return rand.New(e.Randomness)
+	}
+	if e.fallbackRand == nil {
+		return rand.New(&handBuilt)
+	}
+	return rand.New(e.fallbackRand)
+}
+
+// RandReader returns an [io.Reader] that draws bytes from Randomness.
+// With a seeded source, the bytes are reproducible.
+func (e Environment) RandReader() io.Reader {
+	return e.rand()
+}
+
+// isTesting reports whether the Environment identifies itself as a test environment
+func (e Environment) isTesting() bool {
+	return e.Variables["FLARGS_EXE_ENVIRONMENT"] == "testing"
+}
+
+// SecureToken returns n random bytes, hex encoded.
+// Bytes come from [crypto/rand], except in a testing Environment,
+// where they come from [Environment.RandReader] so tests are reproducible.
+func (e Environment) SecureToken(n int) (string, error) {
+	var src io.Reader = crand.Reader
+	if e.isTesting() {
+		src = e.RandReader()
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(src, buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// UUID returns an RFC 4122 version 4 UUID drawn from [Environment.RandReader]
+func (e Environment) UUID() string {
+	var b [16]byte
+	io.ReadFull(e.RandReader(), b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Shuffle puts s in a random order drawn from e's Randomness, so a seeded source shuffles reproducibly.
+// It is a function rather than a method because methods can't have type parameters.
+func Shuffle[T any](e Environment, s []T) {
+	e.rand().Shuffle(len(s), func(i, j int) {
+		s[i], s[j] = s[j], s[i]
+	})
+}
+
+// SampleN returns n elements of s chosen at random from e's Randomness, without replacement, leaving s as it was.
+// If n is more than len(s), all of s is returned, shuffled.
+func SampleN[T any](e Environment, s []T, n int) []T {
+	n = max(min(n, len(s)), 0)
+	pool := slices.Clone(s)
+	r := e.rand()
+	for i := range n {
+		j := i + r.Intn(len(pool)-i)
+		pool[i], pool[j] = pool[j], pool[i]
+	}
+	return pool[:n:n]
+}
@@ -0,0 +1,132 @@
+package flargs_test
+
+import (
+	"math/rand"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_SecureToken(t *testing.T) {
+
+	t.Run("testing environment is deterministic", func(t *testing.T) {
+		a, err := flargs.NewTestingEnvironment(rand.NewSource(7)).SecureToken(16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := flargs.NewTestingEnvironment(rand.NewSource(7)).SecureToken(16)
+		if a != b {
+			t.Errorf("got %s and %s but wanted them equal", a, b)
+		}
+		if len(a) != 32 {
+			t.Errorf("got length %d but wanted 32", len(a))
+		}
+	})
+
+	t.Run("cli environment uses crypto randomness", func(t *testing.T) {
+		env := flargs.NewCLIEnvironment("/")
+		env.Randomness = rand.NewSource(7)
+		a, err := env.SecureToken(16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := env.SecureToken(16)
+		if a == b {
+			t.Errorf("got the same token twice: %s", a)
+		}
+		seeded, _ := flargs.NewTestingEnvironment(rand.NewSource(7)).SecureToken(16)
+		if a == seeded {
+			t.Error("cli token matched the seeded source")
+		}
+	})
+
+}
+
+func TestEnvironment_UUID(t *testing.T) {
+
+	a := flargs.NewTestingEnvironment(rand.NewSource(42)).UUID()
+	b := flargs.NewTestingEnvironment(rand.NewSource(42)).UUID()
+	if a != b {
+		t.Errorf("got %s and %s but wanted them equal", a, b)
+	}
+
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	if !pattern.MatchString(a) {
+		t.Errorf("%s is not a version 4 UUID", a)
+	}
+
+}
+
+func TestShuffle(t *testing.T) {
+
+	deck := func() []int {
+		return []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	}
+	a, b := deck(), deck()
+	flargs.Shuffle(*flargs.NewTestingEnvironment(rand.NewSource(42)), a)
+	flargs.Shuffle(*flargs.NewTestingEnvironment(rand.NewSource(42)), b)
+	if !slices.Equal(a, b) {
+		t.Errorf("got %v and %v but wanted identical shuffles", a, b)
+	}
+	if slices.Equal(a, deck()) {
+		t.Errorf("got %v, which was not shuffled", a)
+	}
+	slices.Sort(a)
+	if !slices.Equal(a, deck()) {
+		t.Errorf("got %v after sorting, so elements were lost", a)
+	}
+
+}
+
+func TestSampleN(t *testing.T) {
+
+	s := []string{"a", "b", "c", "d", "e"}
+	x := flargs.SampleN(*flargs.NewTestingEnvironment(rand.NewSource(3)), s, 3)
+	y := flargs.SampleN(*flargs.NewTestingEnvironment(rand.NewSource(3)), s, 3)
+	if len(x) != 3 || !slices.Equal(x, y) {
+		t.Errorf("got %q and %q but wanted identical samples of 3", x, y)
+	}
+	if !slices.Equal(s, []string{"a", "b", "c", "d", "e"}) {
+		t.Errorf("SampleN modified its input: %q", s)
+	}
+	if all := flargs.SampleN(*flargs.NewTestingEnvironment(rand.NewSource(3)), s, 10); len(all) != len(s) {
+		t.Errorf("got %d elements but wanted all %d", len(all), len(s))
+	}
+
+}
+
+func TestEnvironment_nilRandomness(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	first, second := env.UUID(), env.UUID()
+	if len(first) != 36 {
+		t.Errorf("got UUID %q", first)
+	}
+	if first == second {
+		t.Errorf("got the same UUID %q twice", first)
+	}
+	if _, err := env.SecureToken(8); err != nil {
+		t.Error(err)
+	}
+	for i := range 150 {
+		if _, err := env.CreateTemp("", "nil-*"); err != nil {
+			t.Fatalf("temp file %d: %v", i, err)
+		}
+	}
+	s := []int{1, 2, 3}
+	flargs.Shuffle(*env, s)
+	if got := flargs.SampleN(*env, s, 2); len(got) != 2 {
+		t.Errorf("got a sample of %d but wanted 2", len(got))
+	}
+
+	null := flargs.NewNullEnvironment()
+	if uuid := null.UUID(); len(uuid) != 36 {
+		t.Errorf("got UUID %q from a null environment", uuid)
+	}
+	if got, want := flargs.NewTestingEnvironment(nil).UUID(), first; got != want {
+		t.Errorf("got %q but wanted a fresh Environment to draw %q, whatever others drew before it", got, want)
+	}
+
+}
@@ -0,0 +1,34 @@
+package flargs
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type exitReport struct {
+	Code     int           `json:"code"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration,omitempty"`
+	Args     []string      `json:"args"`
+}
+
+// WriteExitReport writes a JSON record of how a command concluded to path on the Filesystem,
+// so CI can classify failures. Duration is in nanoseconds, measured by the [Clock] since StartTime,
+// and is omitted if StartTime was never recorded. code is the process exit code, as handed to [os.Exit].
+func (e Environment) WriteExitReport(code int, err error, path string) error {
+	report := exitReport{
+		Code: code,
+		Args: e.Arguments,
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+	if !e.StartTime.IsZero() {
+		report.Duration = e.Clock.Now().Sub(e.StartTime)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	return e.Filesystem.WriteFile(e.ResolvePath(path), data, e.FileMode())
+}
@@ -0,0 +1,54 @@
+package flargs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_WriteExitReport(t *testing.T) {
+
+	env := flargs.NewDeterministicEnvironment(0)
+	env.Arguments = []string{"kat", "missing.txt"}
+	env.SetClock(flargs.FixedClock{Time: env.StartTime.Add(1500 * time.Millisecond)})
+
+	cmd := flargs.CommandFunc(func(*flargs.Environment) error {
+		return flargs.NewFlargError(flargs.ExitCodeCannotExecute, errors.New("missing.txt: no such file"))
+	})
+	cmd.Environment = env
+	code := cmd.Execute(nil)
+
+	if err := env.WriteExitReport(int(code), errors.New("missing.txt: no such file"), "report.json"); err != nil {
+		t.Fatal(err)
+	}
+	data, err := env.Filesystem.ReadFile("report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Code     int      `json:"code"`
+		Error    string   `json:"error"`
+		Duration int64    `json:"duration"`
+		Args     []string `json:"args"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Code != 126 {
+		t.Errorf("got code %d but wanted 126", got.Code)
+	}
+	if got.Error != "missing.txt: no such file" {
+		t.Errorf("got error %q", got.Error)
+	}
+	if got.Duration != int64(1500*time.Millisecond) {
+		t.Errorf("got duration %d", got.Duration)
+	}
+	if !slices.Equal(got.Args, env.Arguments) {
+		t.Errorf("got args %q", got.Args)
+	}
+
+}
@@ -0,0 +1,65 @@
+package flargs
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// a Router dispatches to subcommands by name, as in "git commit" or "go test"
+type Router map[string]Command
+
+// Register adds c as the subcommand called name.
+// Registering a name that is already taken is an error, rather than a silent replacement.
+func (r Router) Register(name string, c Command) error {
+	if name == "" {
+		return fmt.Errorf("router: empty subcommand name")
+	}
+	if _, taken := r[name]; taken {
+		return fmt.Errorf("router: subcommand %q is already registered", name)
+	}
+	r[name] = c
+	return nil
+}
+
+// MustRegister is [Router.Register], but panics on error. It suits wiring done once at startup.
+func (r Router) MustRegister(name string, c Command) {
+	if err := r.Register(name, c); err != nil {
+		panic(err)
+	}
+}
+
+// Names returns the registered subcommand names, sorted
+func (r Router) Names() []string {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// WriteUsage lists the subcommands to w, one per line
+func (r Router) WriteUsage(w io.Writer) {
+	fmt.Fprintln(w, "subcommands:")
+	for _, name := range r.Names() {
+		fmt.Fprintf(w, "  %s\n", name)
+	}
+}
+
+// Execute runs the subcommand named by args[0] against env, passing it the rest of args.
+// A missing or unknown subcommand is written to env's ErrorStream and exits with [ExitCodeCommandNotFound].
+func (r Router) Execute(env *Environment, args []string) ExitCode {
+	if len(args) == 0 {
+		fmt.Fprintln(env.ErrorStream, env.FormatError(fmt.Errorf("missing subcommand: want one of %s", strings.Join(r.Names(), ", "))))
+		return ExitCodeCommandNotFound
+	}
+	c, exists := r[args[0]]
+	if !exists {
+		fmt.Fprintln(env.ErrorStream, env.FormatError(fmt.Errorf("unknown subcommand %q: want one of %s", args[0], strings.Join(r.Names(), ", "))))
+		return ExitCodeCommandNotFound
+	}
+	c.Environment = env
+	return c.Execute(args[1:])
+}
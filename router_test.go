@@ -0,0 +1,66 @@
+package flargs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestRouter_Register(t *testing.T) {
+
+	r := flargs.Router{}
+	if err := r.Register("echo", flargs.EchoInput()); err != nil {
+		t.Fatal(err)
+	}
+	err := r.Register("echo", flargs.NoOp())
+	if err == nil || !strings.Contains(err.Error(), `"echo" is already registered`) {
+		t.Errorf("got %v but wanted a duplicate registration error", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustRegister did not panic on a duplicate")
+		}
+	}()
+	r.MustRegister("echo", flargs.NoOp())
+
+}
+
+func TestRouter_Execute(t *testing.T) {
+
+	r := flargs.Router{}
+	r.MustRegister("echo", flargs.EchoInput())
+	r.MustRegister("noop", flargs.NoOp())
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.InputStream.Write([]byte("ping"))
+	if code := r.Execute(env, []string{"echo"}); code != flargs.ExitCodeSuccess {
+		t.Errorf("got exit code %d", code)
+	}
+	if got := env.OutputString(); got != "ping" {
+		t.Errorf("got %q but wanted %q", got, "ping")
+	}
+
+	env = flargs.NewTestingEnvironment(nil)
+	if code := r.Execute(env, []string{"ehco"}); code != flargs.ExitCodeCommandNotFound {
+		t.Errorf("got exit code %d but wanted %d", code, flargs.ExitCodeCommandNotFound)
+	}
+	if got, want := string(env.GetError()), "error: unknown subcommand \"ehco\": want one of echo, noop\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestRouter_WriteUsage(t *testing.T) {
+
+	r := flargs.Router{}
+	r.MustRegister("push", flargs.NoOp())
+	r.MustRegister("pull", flargs.NoOp())
+	var buf strings.Builder
+	r.WriteUsage(&buf)
+	if got, want := buf.String(), "subcommands:\n  pull\n  push\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
@@ -0,0 +1,107 @@
+package flargs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcServerError    = -32000
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// ServeStdio serves methods as JSON-RPC 2.0 over the Environment's streams, as a language server does.
+// Requests are read one per line from InputStream, and responses written one per line to OutputStream.
+// Notifications, which have no id, get no response. Failures are also logged to ErrorStream.
+// It returns nil when InputStream is exhausted, or the context's error once ctx is done,
+// which is noticed between requests.
+func ServeStdio(ctx context.Context, e *Environment, methods map[string]func(json.RawMessage) (any, error)) error {
+	ctx = e.ctxOr(ctx)
+	in := bufio.NewReader(e.InputStream)
+	enc := json.NewEncoder(e.OutputStream)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := in.ReadBytes('\n')
+		if len(line) > 0 {
+			if res, respond := serveRPC(e, methods, line); respond {
+				if werr := enc.Encode(res); werr != nil {
+					return werr
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// serveRPC handles one request, reporting whether a response is due
+func serveRPC(e *Environment, methods map[string]func(json.RawMessage) (any, error), line []byte) (rpcResponse, bool) {
+	res := rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+	fail := func(code int, err error) (rpcResponse, bool) {
+		fmt.Fprintf(e.ErrorStream, "rpc: %v\n", err)
+		res.Error = &rpcError{Code: code, Message: err.Error()}
+		return res, true
+	}
+	if len(bytes.TrimSpace(line)) == 0 {
+		return res, false
+	}
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return fail(rpcParseError, err)
+	}
+	notification := len(req.ID) == 0
+	if !notification {
+		res.ID = req.ID
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		return fail(rpcInvalidRequest, errors.New("invalid request"))
+	}
+	method, exists := methods[req.Method]
+	if !exists {
+		res, _ := fail(rpcMethodNotFound, fmt.Errorf("method not found: %s", req.Method))
+		return res, !notification
+	}
+	result, err := method(req.Params)
+	if err != nil {
+		res, _ := fail(rpcServerError, fmt.Errorf("%s: %w", req.Method, err))
+		return res, !notification
+	}
+	res.Result = result
+	if result == nil {
+		res.Result = json.RawMessage("null")
+	}
+	return res, !notification
+}
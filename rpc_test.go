@@ -0,0 +1,61 @@
+package flargs_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestServeStdio(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fmt.Fprintln(env.InputStream, `{"jsonrpc":"2.0","id":1,"method":"add","params":[2,3]}`)
+	fmt.Fprintln(env.InputStream, `{"jsonrpc":"2.0","method":"add","params":[0,0]}`)
+	fmt.Fprintln(env.InputStream, `{"jsonrpc":"2.0","id":"b","method":"explode"}`)
+	fmt.Fprintln(env.InputStream, `{"jsonrpc":"2.0","id":3,"method":"missing"}`)
+
+	methods := map[string]func(json.RawMessage) (any, error){
+		"add": func(params json.RawMessage) (any, error) {
+			var operands []int
+			if err := json.Unmarshal(params, &operands); err != nil {
+				return nil, err
+			}
+			sum := 0
+			for _, n := range operands {
+				sum += n
+			}
+			return sum, nil
+		},
+		"explode": func(json.RawMessage) (any, error) {
+			return nil, errors.New("kaboom")
+		},
+	}
+
+	if err := flargs.ServeStdio(context.Background(), env, methods); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Split(env.OutputTrimmed(), "\n")
+	want := []string{
+		`{"jsonrpc":"2.0","id":1,"result":5}`,
+		`{"jsonrpc":"2.0","id":"b","error":{"code":-32000,"message":"explode: kaboom"}}`,
+		`{"jsonrpc":"2.0","id":3,"error":{"code":-32601,"message":"method not found: missing"}}`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d responses but wanted %d: %q", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %s but wanted %s", got[i], want[i])
+		}
+	}
+	if errs := string(env.GetError()); !strings.Contains(errs, "kaboom") {
+		t.Errorf("got stderr %q but wanted the failure logged", errs)
+	}
+
+}
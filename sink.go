@@ -0,0 +1,169 @@
+package flargs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"sync"
+
+	rfs "github.com/sean9999/go-real-fs"
+)
+
+// a sink is an append-only, file-backed stream that can be reopened in place,
+// so an external log rotator can move the old file out from under it
+type sink struct {
+	mu   sync.Mutex
+	fsys rfs.WritableFs
+	path string
+	mode fs.FileMode
+	file rfs.WritableFile
+}
+
+func (s *sink) open() error {
+	f, err := s.fsys.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, s.mode)
+	if err != nil {
+		return err
+	}
+	s.file = f
+	return nil
+}
+
+func (s *sink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Write(p)
+}
+
+// Read always reports EOF. Sinks are for writing.
+func (s *sink) Read(_ []byte) (int, error) {
+	return 0, io.EOF
+}
+
+// reopen opens the file now at the sink's path, and only then closes the old one,
+// so a failed reopen leaves the sink writing where it was
+func (s *sink) reopen() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.file
+	if err := s.open(); err != nil {
+		return err
+	}
+	return old.Close()
+}
+
+// a sinkSet is the sinks of an Environment, by path. It is shared by copies of the Environment,
+// and guarded so [Environment.ReopenOnHUP] can reopen them while more are added.
+type sinkSet struct {
+	mu    sync.Mutex
+	sinks map[string]*sink
+}
+
+// sinkSet returns the Environment's sinks, creating the set on first use
+func (e *Environment) sinkSet() *sinkSet {
+	if e.sinks == nil {
+		e.sinks = &sinkSet{sinks: map[string]*sink{}}
+	}
+	return e.sinks
+}
+
+func (e *Environment) addSink(path string) (*sink, error) {
+	path = e.ResolvePath(path)
+	s := &sink{fsys: e.Filesystem, path: path, mode: e.FileMode()}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	set := e.sinkSet()
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	set.sinks[path] = s
+	return s, nil
+}
+
+// SinkOutputTo points OutputStream at a file on the Filesystem, opened for appending
+func (e *Environment) SinkOutputTo(path string) error {
+	s, err := e.addSink(path)
+	if err != nil {
+		return err
+	}
+	e.OutputStream = s
+	return nil
+}
+
+// SinkErrorsTo points ErrorStream at a file on the Filesystem, opened for appending
+func (e *Environment) SinkErrorsTo(path string) error {
+	s, err := e.addSink(path)
+	if err != nil {
+		return err
+	}
+	e.ErrorStream = s
+	return nil
+}
+
+// Reopen closes and reopens the named sink files, or all of them if no paths are given.
+// Writes that follow land in whatever file is now at each path.
+func (e *Environment) Reopen(paths ...string) error {
+	resolved := make([]string, len(paths))
+	for i, p := range paths {
+		resolved[i] = e.ResolvePath(p)
+	}
+	return e.sinkSet().reopen(resolved)
+}
+
+// reopen reopens the sinks at paths, which are already resolved, or all of them if there are none
+func (set *sinkSet) reopen(paths []string) error {
+	set.mu.Lock()
+	if len(paths) == 0 {
+		for p := range set.sinks {
+			paths = append(paths, p)
+		}
+	}
+	sinks := make([]*sink, len(paths))
+	for i, p := range paths {
+		sinks[i] = set.sinks[p]
+	}
+	set.mu.Unlock()
+	for i, s := range sinks {
+		if s == nil {
+			return fmt.Errorf("%s is not a sink", paths[i])
+		}
+		if err := s.reopen(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReopenOnHUP calls [Environment.Reopen] with paths every time the process receives SIGHUP.
+// It stops listening when the Environment's Context is done.
+// Set up sinks before calling it. It does nothing in a testing Environment, or under js/wasm, which has no SIGHUP;
+// call Reopen directly to simulate the signal.
+func (e *Environment) ReopenOnHUP(paths ...string) {
+	if e.isTesting() {
+		return
+	}
+	//	everything the goroutine needs is gathered now, so it never reads the Environment while it changes
+	set := e.sinkSet()
+	resolved := make([]string, len(paths))
+	for i, p := range paths {
+		resolved[i] = e.ResolvePath(p)
+	}
+	errs := e.ErrorStream
+	hup := make(chan os.Signal, 1)
+	notifyHUP(hup)
+	done := e.ctxOr(nil).Done()
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+				if err := set.reopen(resolved); err != nil {
+					fmt.Fprintln(errs, err)
+				}
+			}
+		}
+	}()
+}
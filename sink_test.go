@@ -0,0 +1,106 @@
+package flargs_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+	realfs "github.com/sean9999/go-real-fs"
+)
+
+func TestEnvironment_Reopen(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	if err := env.SinkErrorsTo("/var/log/app.log"); err != nil {
+		t.Fatal(err)
+	}
+	env.ReopenOnHUP()
+
+	fmt.Fprintln(env.ErrorStream, "before rotation")
+
+	//	an external rotator moves the file away
+	old, _ := env.Filesystem.ReadFile("/var/log/app.log")
+	env.Filesystem.WriteFile("/var/log/app.log.1", old, 0644)
+	env.Filesystem.Remove("/var/log/app.log")
+
+	if err := env.Reopen("/var/log/app.log"); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(env.ErrorStream, "after rotation")
+
+	rotated, _ := env.Filesystem.ReadFile("/var/log/app.log.1")
+	if got, want := string(rotated), "before rotation\n"; got != want {
+		t.Errorf("rotated file: got %q but wanted %q", got, want)
+	}
+	current, _ := env.Filesystem.ReadFile("/var/log/app.log")
+	if got, want := string(current), "after rotation\n"; got != want {
+		t.Errorf("current file: got %q but wanted %q", got, want)
+	}
+
+	if err := env.Reopen("/not/a/sink"); err == nil {
+		t.Error("wanted an error reopening an unknown path")
+	}
+
+}
+
+// failingOpenFS is a [flargs.MemFS] whose OpenFile fails once fail is set
+type failingOpenFS struct {
+	*flargs.MemFS
+	fail *bool
+}
+
+func (f failingOpenFS) OpenFile(name string, flag int, perm fs.FileMode) (realfs.WritableFile, error) {
+	if *f.fail {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MemFS.OpenFile(name, flag, perm)
+}
+
+func TestEnvironment_Reopen_fails(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fail := false
+	env.Filesystem = failingOpenFS{flargs.NewMemFS(), &fail}
+	if err := env.SinkOutputTo("/app.log"); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintln(env.OutputStream, "before")
+	fail = true
+	if err := env.Reopen(); !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("got %v but wanted %v", err, fs.ErrPermission)
+	}
+	if _, err := fmt.Fprintln(env.OutputStream, "after"); err != nil {
+		t.Errorf("got %v writing after a failed reopen", err)
+	}
+	data, _ := env.Filesystem.ReadFile("/app.log")
+	if got, want := string(data), "before\nafter\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestEnvironment_Reopen_concurrent(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	if err := env.SinkErrorsTo("/errors.log"); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			env.Reopen()
+		}
+	}()
+	for i := range 50 {
+		if err := env.SinkOutputTo(fmt.Sprintf("/out%d.log", i)); err != nil {
+			t.Error(err)
+		}
+	}
+	wg.Wait()
+
+}
@@ -0,0 +1,124 @@
+package flargs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	rfs "github.com/sean9999/go-real-fs"
+)
+
+// spillWriter keeps writes in memory until they pass limit, then moves everything to a temporary file
+type spillWriter struct {
+	env    Environment
+	limit  int64
+	mem    bytes.Buffer
+	file   rfs.WritableFile
+	name   string
+	err    error
+	closed bool
+}
+
+func (s *spillWriter) Write(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	if s.file == nil && int64(s.mem.Len()+len(p)) > s.limit {
+		f, _, err := s.env.createTemp("", "flargs-spill-*", s.env.FileMode()&0600)
+		if err != nil {
+			s.err = err
+			return 0, err
+		}
+		s.file, s.name = f, f.Name()
+		if _, err := f.Write(s.mem.Bytes()); err != nil {
+			s.err = err
+			return 0, err
+		}
+		s.mem = bytes.Buffer{}
+	}
+	if s.file != nil {
+		//	a reader may have moved the handle, and writes always append
+		if _, err := s.file.Seek(0, io.SeekEnd); err != nil {
+			s.err = err
+			return 0, err
+		}
+		n, err := s.file.Write(p)
+		if err != nil {
+			s.err = err
+		}
+		return n, err
+	}
+	return s.mem.Write(p)
+}
+
+// Close closes and removes the temporary file, if there is one. What was written can't be read after that.
+func (s *spillWriter) Close() error {
+	s.closed = true
+	if s.file == nil {
+		return nil
+	}
+	return errors.Join(s.file.Close(), s.env.Filesystem.Remove(s.name))
+}
+
+// reader reads everything written so far, from memory or from the temporary file.
+// A file reader shares the writer's one handle, keeping its own place in the file, so nothing is left open.
+func (s *spillWriter) reader() io.Reader {
+	if s.closed {
+		return errReader{fs.ErrClosed}
+	}
+	if s.file == nil {
+		return bytes.NewReader(s.mem.Bytes())
+	}
+	return &spillReader{s: s}
+}
+
+// spillReader reads a spillWriter's temporary file from its own offset
+type spillReader struct {
+	s      *spillWriter
+	offset int64
+}
+
+func (r *spillReader) Read(p []byte) (int, error) {
+	if r.s.closed {
+		return 0, fs.ErrClosed
+	}
+	if _, err := r.s.file.Seek(r.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := r.s.file.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// errReader fails every Read with err
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(_ []byte) (int, error) {
+	return 0, r.err
+}
+
+// SpillWriter captures output that is usually small, but might not be.
+// Writes are kept in memory until they would pass memLimit bytes,
+// then everything moves to a temporary file, named as by [Environment.CreateTemp].
+// In a [Environment.DryRun], that file is in a throwaway [MemFS].
+// The returned function gives a reader over all that has been written so far. Read what you need before closing the writer:
+// Close removes the temporary file, and readers made after it fail with [fs.ErrClosed].
+func (e Environment) SpillWriter(memLimit int64) (io.WriteCloser, func() io.Reader, error) {
+	if memLimit < 0 {
+		return nil, nil, fmt.Errorf("spill: invalid memory limit %d", memLimit)
+	}
+	if e.Filesystem == nil {
+		return nil, nil, errors.New("spill: no filesystem to spill to")
+	}
+	//	scratch space isn't an intended operation, but a dry run still mustn't touch the Filesystem:
+	//	the spill goes to a MemFS of its own, from which Close removes it
+	if e.DryRun() {
+		e.Filesystem = NewMemFS()
+	}
+	s := &spillWriter{env: e, limit: memLimit}
+	return s, s.reader, nil
+}
@@ -0,0 +1,105 @@
+package flargs_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_SpillWriter(t *testing.T) {
+
+	t.Run("under the limit", func(t *testing.T) {
+		env := flargs.NewDeterministicEnvironment(0)
+		w, read, err := env.SpillWriter(64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprint(w, "small")
+		got, _ := io.ReadAll(read())
+		w.Close()
+		if string(got) != "small" {
+			t.Errorf("got %q but wanted %q", got, "small")
+		}
+		if entries, _ := env.ReadDir("/tmp"); len(entries) != 0 {
+			t.Errorf("got %d temporary files but wanted none", len(entries))
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		env := flargs.NewDeterministicEnvironment(0)
+		trace := new(bytes.Buffer)
+		env.Filesystem = flargs.TracingFS(env.Filesystem, trace)
+		w, read, err := env.SpillWriter(16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range 10 {
+			fmt.Fprintf(w, "line %d\n", i)
+		}
+		got, err := io.ReadAll(read())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Count(string(got), "\n"); lines != 10 || !strings.HasPrefix(string(got), "line 0\n") {
+			t.Errorf("got %q but wanted all 10 lines", got)
+		}
+
+		//	a reader keeps its place while more is written, and a new one starts from the top
+		first := read()
+		head := make([]byte, 7)
+		io.ReadFull(first, head)
+		fmt.Fprint(w, "tail\n")
+		rest, _ := io.ReadAll(first)
+		if got, want := string(head)+string(rest), string(got)+"tail\n"; got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+		if again, _ := io.ReadAll(read()); !strings.HasSuffix(string(again), "line 9\ntail\n") {
+			t.Errorf("got %q but wanted everything written", again)
+		}
+		if entries, _ := env.ReadDir("/tmp"); len(entries) != 1 {
+			t.Errorf("got %d temporary files but wanted the output spilled to one", len(entries))
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if entries, _ := env.ReadDir("/tmp"); len(entries) != 0 {
+			t.Errorf("got %d temporary files after Close but wanted none", len(entries))
+		}
+		if _, err := io.ReadAll(read()); !errors.Is(err, fs.ErrClosed) {
+			t.Errorf("got %v but wanted %v", err, fs.ErrClosed)
+		}
+		if strings.Contains(trace.String(), "fs: Open ") {
+			t.Errorf("readers opened handles of their own:\n%s", trace)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		env := flargs.NewDeterministicEnvironment(0)
+		env.Variables["FLARGS_DRY_RUN"] = "1"
+		w, read, err := env.SpillWriter(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprint(w, "more than four bytes")
+		got, err := io.ReadAll(read())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "more than four bytes" {
+			t.Errorf("got %q but wanted %q", got, "more than four bytes")
+		}
+		if entries, _ := env.ReadDir("/tmp"); len(entries) != 0 {
+			t.Errorf("got %d temporary files on the Filesystem in a dry run", len(entries))
+		}
+		if err := w.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+
+}
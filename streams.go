@@ -0,0 +1,581 @@
+package flargs
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// readWriter stitches a separate Reader and Writer into an [io.ReadWriter]
+type readWriter struct {
+	io.Reader
+	io.Writer
+}
+
+// inputFiles is the InputStream [Environment.SetInputFiles] makes. Closing it closes the files, but not the original stream.
+type inputFiles struct {
+	readWriter
+	files []fs.File
+}
+
+func (i inputFiles) Close() error {
+	var errs []error
+	for _, f := range i.files {
+		errs = append(errs, f.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// SetInputFiles replaces InputStream with the concatenation of the named files, read from the Filesystem.
+// The name "-" stands for the original InputStream, as in `cat a.txt - b.txt`.
+// Writes to the new InputStream still go to the original one.
+// The files stay open until the new InputStream is closed, which [Command.Execute] does when it finishes.
+// If any file can't be opened, those already opened are closed, and InputStream is left as it was.
+func (e *Environment) SetInputFiles(paths ...string) error {
+	original := e.InputStream
+	readers := make([]io.Reader, 0, len(paths))
+	var files []fs.File
+	for _, p := range paths {
+		if p == "-" {
+			readers = append(readers, original)
+			continue
+		}
+		f, err := e.Filesystem.Open(e.ResolvePath(p))
+		if err != nil {
+			inputFiles{files: files}.Close()
+			return err
+		}
+		readers = append(readers, f)
+		files = append(files, f)
+	}
+	e.InputStream = inputFiles{readWriter{io.MultiReader(readers...), original}, files}
+	return nil
+}
+
+// mergeMu serializes [Environment.Fork] merges, so merged output never interleaves
+var mergeMu sync.Mutex
+
+// Fork returns a child Environment with its own empty stream buffers, for use in a separate goroutine.
+// Filesystem, Variables and everything else are shared with the parent.
+// Calling merge appends whatever the child wrote to its OutputStream and ErrorStream onto the parent's.
+// Merges from many children are serialized, so each child's output lands in one piece.
+func (e *Environment) Fork() (child *Environment, merge func() error) {
+	c := *e
+	c.InputStream = new(bytes.Buffer)
+	c.OutputStream = new(bytes.Buffer)
+	c.ErrorStream = new(bytes.Buffer)
+	merge = func() error {
+		mergeMu.Lock()
+		defer mergeMu.Unlock()
+		if _, err := io.Copy(e.OutputStream, c.OutputStream); err != nil {
+			return err
+		}
+		_, err := io.Copy(e.ErrorStream, c.ErrorStream)
+		return err
+	}
+	return &c, merge
+}
+
+// ErrInputTooLarge is returned by a [Environment.LimitedInput] reader once the input exceeds its limit
+var ErrInputTooLarge = errors.New("input too large")
+
+// LimitedInput wraps InputStream so that reading more than maxBytes fails with [ErrInputTooLarge],
+// rather than silently truncating as [io.LimitReader] would.
+func (e Environment) LimitedInput(maxBytes int64) io.Reader {
+	return &limitedReader{e.InputStream, maxBytes}
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		//	at the limit. Input that ends here is fine. Anything more is not.
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, ErrInputTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// gzipMagic opens every gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// AutoDecompressInput returns InputStream, decompressed if it is gzipped, so commands accept plain and compressed input alike.
+// Gzip is detected by its magic number, whatever the input is called.
+func (e Environment) AutoDecompressInput() (io.Reader, error) {
+	br := bufio.NewReader(e.InputStream)
+	head, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !bytes.Equal(head, gzipMagic) {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+// InputFile returns the [os.File] behind InputStream, if there is one.
+// It's an escape hatch for things like ioctl. Prefer InputStream.
+func (e Environment) InputFile() (*os.File, bool) {
+	f, ok := e.InputStream.(*os.File)
+	return f, ok
+}
+
+// OutputFile returns the [os.File] behind OutputStream, if there is one
+func (e Environment) OutputFile() (*os.File, bool) {
+	f, ok := e.OutputStream.(*os.File)
+	return f, ok
+}
+
+// ErrorFile returns the [os.File] behind ErrorStream, if there is one
+func (e Environment) ErrorFile() (*os.File, bool) {
+	f, ok := e.ErrorStream.(*os.File)
+	return f, ok
+}
+
+// SetFD registers r as file descriptor n for [Environment.ReadFromFD], so tests can pass secrets like `--password-fd 3` would
+func (e *Environment) SetFD(n int, r io.Reader) {
+	if e.fds == nil {
+		e.fds = map[int]io.Reader{}
+	}
+	e.fds[n] = r
+}
+
+// ReadFromFD reads file descriptor n to the end, as for `--password-fd 3`.
+// A descriptor registered with [Environment.SetFD] is read from instead.
+// Only a CLI Environment reads from real descriptors, which are closed once read,
+// except for 0, 1 and 2, which are read through [os.Stdin], [os.Stdout] and [os.Stderr] and left open.
+func (e Environment) ReadFromFD(n int) ([]byte, error) {
+	if r, ok := e.fds[n]; ok {
+		return io.ReadAll(r)
+	}
+	if e.Variables["FLARGS_EXE_ENVIRONMENT"] != "cli" {
+		return nil, fmt.Errorf("file descriptor %d: %w", n, os.ErrNotExist)
+	}
+	switch n {
+	case 0:
+		return io.ReadAll(os.Stdin)
+	case 1:
+		return io.ReadAll(os.Stdout)
+	case 2:
+		return io.ReadAll(os.Stderr)
+	}
+	f := os.NewFile(uintptr(n), fmt.Sprintf("fd%d", n))
+	if f == nil {
+		return nil, fmt.Errorf("file descriptor %d: %w", n, os.ErrInvalid)
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// SyncWriter guards w with a mutex, so each Write lands whole even when called from many goroutines.
+// If w is also an [io.Reader], reads are guarded too and the result is an [io.ReadWriter].
+func SyncWriter(w io.Writer) io.Writer {
+	return &syncStream{w: w}
+}
+
+type syncStream struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+func (s *syncStream) snapshot() ([]byte, bool) {
+	buf, ok := s.w.(*bytes.Buffer)
+	if !ok {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return bytes.Clone(buf.Bytes()), true
+}
+
+func (s *syncStream) Read(p []byte) (int, error) {
+	r, ok := s.w.(io.Reader)
+	if !ok {
+		return 0, io.EOF
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return r.Read(p)
+}
+
+// CallbackWriter returns an [io.Writer] that hands every Write to fn, for hosts that want output as events.
+// An error from fn is returned from Write. The result is also an [io.ReadWriter] whose reads always report EOF,
+// so it can stand in as an OutputStream or ErrorStream.
+func CallbackWriter(fn func([]byte) error) io.Writer {
+	return callbackWriter(fn)
+}
+
+type callbackWriter func([]byte) error
+
+func (fn callbackWriter) Write(p []byte) (int, error) {
+	if err := fn(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (fn callbackWriter) Read(_ []byte) (int, error) {
+	return 0, io.EOF
+}
+
+// HeadBytes reads the first n bytes of InputStream, or all of it if there is less, like `head -c`
+func (e Environment) HeadBytes(n int64) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(e.InputStream, n))
+}
+
+// TailBytes reads InputStream to the end and returns the last n bytes, like `tail -c`.
+// At most n bytes are held in memory at a time, and no more than has been read.
+func (e Environment) TailBytes(n int64) ([]byte, error) {
+	if n <= 0 {
+		_, err := io.Copy(io.Discard, e.InputStream)
+		return []byte{}, err
+	}
+	//	ring fills up to n bytes, then wraps, with pos marking the oldest byte
+	var ring []byte
+	pos := 0
+	chunk := make([]byte, 32*1024)
+	for {
+		read, err := e.InputStream.Read(chunk)
+		p := chunk[:read]
+		if int64(len(p)) >= n {
+			p = p[int64(len(p))-n:]
+		}
+		if room := n - int64(len(ring)); room > 0 && len(p) > 0 {
+			k := int(min(room, int64(len(p))))
+			if len(ring)+k > cap(ring) {
+				grown := make([]byte, len(ring), min(n, int64(max(2*cap(ring), len(ring)+k))))
+				copy(grown, ring)
+				ring = grown
+			}
+			ring = append(ring, p[:k]...)
+			p = p[k:]
+		}
+		for len(p) > 0 {
+			k := copy(ring[pos:], p)
+			p = p[k:]
+			pos = (pos + k) % len(ring)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return append(ring[pos:len(ring):len(ring)], ring[:pos]...), nil
+}
+
+// copyChunkSize is how much [Environment.Copy] moves between checks of its context
+const copyChunkSize = 32 * 1024
+
+// Copy copies InputStream to OutputStream in bounded chunks,
+// stopping with the context's error as soon as ctx is done. A nil ctx means the Environment's Context.
+func (e Environment) Copy(ctx context.Context) (int64, error) {
+	return copyCtx(e.ctxOr(ctx), e.OutputStream, e.InputStream)
+}
+
+// copyCtx copies src to dst a chunk at a time, checking ctx before each chunk
+func copyCtx(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	buf := make([]byte, copyChunkSize)
+	var written int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			w, err := dst.Write(buf[:n])
+			written += int64(w)
+			if err != nil {
+				return written, err
+			}
+		}
+		if readErr == io.EOF {
+			return written, nil
+		}
+		if readErr != nil {
+			return written, readErr
+		}
+	}
+}
+
+// InputChannel reads InputStream in the background, delivering chunks as they arrive, so stdin can be
+// multiplexed with other events in a select. The data channel closes at EOF, on a read error, or once ctx is done.
+// A read error, or the context's error, is then sent on the error channel, which closes too.
+// A Read that blocks is left to finish in the background, but nothing is delivered after ctx is done.
+func (e Environment) InputChannel(ctx context.Context) (<-chan []byte, <-chan error) {
+	ctx = e.ctxOr(ctx)
+	data := make(chan []byte)
+	errc := make(chan error, 1)
+	type result struct {
+		chunk []byte
+		err   error
+	}
+	reads := make(chan result)
+	go func() {
+		buf := make([]byte, copyChunkSize)
+		for {
+			n, err := e.InputStream.Read(buf)
+			select {
+			case reads <- result{bytes.Clone(buf[:n]), err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		defer close(errc)
+		defer close(data)
+		for {
+			select {
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			case r := <-reads:
+				if len(r.chunk) > 0 {
+					select {
+					case data <- r.chunk:
+					case <-ctx.Done():
+						errc <- ctx.Err()
+						return
+					}
+				}
+				if r.err == io.EOF {
+					return
+				}
+				if r.err != nil {
+					errc <- r.err
+					return
+				}
+			}
+		}
+	}()
+	return data, errc
+}
+
+// bufferedStream batches writes to an underlying stream until Flush
+type bufferedStream struct {
+	*bufio.Writer
+	under io.ReadWriter
+}
+
+func (b bufferedStream) Read(p []byte) (int, error) {
+	if err := b.Flush(); err != nil {
+		return 0, err
+	}
+	return b.under.Read(p)
+}
+
+func (b bufferedStream) snapshot() ([]byte, bool) {
+	if err := b.Flush(); err != nil {
+		return nil, false
+	}
+	return readStream(b.under), true
+}
+
+// BufferOutput wraps OutputStream in a [bufio.Writer], for commands that write in many small pieces.
+// [Command.Execute] flushes it before returning, even on failure. Otherwise, call [Environment.Flush].
+func (e *Environment) BufferOutput() {
+	e.OutputStream = bufferedStream{bufio.NewWriter(e.OutputStream), e.OutputStream}
+}
+
+// Flush flushes any stream that buffers, such as one set up by [Environment.BufferOutput]
+func (e Environment) Flush() error {
+	var errs []error
+	for _, stream := range []io.ReadWriter{e.OutputStream, e.ErrorStream} {
+		if f, ok := stream.(interface{ Flush() error }); ok {
+			errs = append(errs, f.Flush())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// closeStreams flushes, then closes InputStream and OutputStream if they can be closed.
+// ErrorStream is left open, so a failure can still be reported on it. See [closeStream].
+func (e Environment) closeStreams() error {
+	return errors.Join(e.Flush(), closeStream(e.InputStream), closeStream(e.OutputStream))
+}
+
+// closeStream closes stream if it can be closed, unless it is an [os.File], which belongs to the process
+func closeStream(stream io.ReadWriter) error {
+	if _, isFile := stream.(*os.File); isFile {
+		return nil
+	}
+	if c, ok := stream.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// PushOutput replaces OutputStream with w, until the returned restore function puts the previous one back.
+// Pushes nest, so each restore undoes exactly its own push.
+func (e *Environment) PushOutput(w io.ReadWriter) (restore func()) {
+	prev := e.OutputStream
+	e.OutputStream = w
+	return func() { e.OutputStream = prev }
+}
+
+// PushInput is [Environment.PushOutput] for InputStream
+func (e *Environment) PushInput(r io.ReadWriter) (restore func()) {
+	prev := e.InputStream
+	e.InputStream = r
+	return func() { e.InputStream = prev }
+}
+
+// PushError is [Environment.PushOutput] for ErrorStream
+func (e *Environment) PushError(w io.ReadWriter) (restore func()) {
+	prev := e.ErrorStream
+	e.ErrorStream = w
+	return func() { e.ErrorStream = prev }
+}
+
+// RedirectErrorToOutput points ErrorStream at OutputStream, as `2>&1` does, until [Environment.RestoreError].
+// Redirecting again before restoring changes nothing.
+func (e *Environment) RedirectErrorToOutput() {
+	if e.restoreError == nil {
+		e.restoreError = e.PushError(e.OutputStream)
+	}
+}
+
+// RestoreError undoes [Environment.RedirectErrorToOutput]. It is a no-op if ErrorStream isn't redirected.
+func (e *Environment) RestoreError() {
+	if e.restoreError != nil {
+		e.restoreError()
+		e.restoreError = nil
+	}
+}
+
+// ringBuffer keeps the last len(buf) bytes written to it
+type ringBuffer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	buf  []byte
+	next int
+	full bool
+}
+
+func (r *ringBuffer) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, err := r.w.Write(p)
+	kept := p[:n]
+	if size := len(r.buf); size > 0 {
+		if len(kept) >= size {
+			copy(r.buf, kept[len(kept)-size:])
+			r.next, r.full = 0, true
+		} else {
+			c := copy(r.buf[r.next:], kept)
+			copy(r.buf, kept[c:])
+			if r.next+len(kept) >= size {
+				r.full = true
+			}
+			r.next = (r.next + len(kept)) % size
+		}
+	}
+	return n, err
+}
+
+func (r *ringBuffer) contents() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return bytes.Clone(r.buf[:r.next])
+	}
+	return append(bytes.Clone(r.buf[r.next:]), r.buf[:r.next]...)
+}
+
+// RingBufferTee writes through to w, keeping the most recent size bytes in memory,
+// for something like a /healthz dump of a daemon's recent logs.
+// The returned function reports what is kept. Both are safe for concurrent use.
+func RingBufferTee(w io.Writer, size int) (io.Writer, func() []byte) {
+	r := &ringBuffer{w: w, buf: make([]byte, max(size, 0))}
+	return r, r.contents
+}
+
+// ringStream is a stream whose writes also go to a ring buffer
+type ringStream struct {
+	io.Writer
+	under io.ReadWriter
+}
+
+func (r ringStream) Read(p []byte) (int, error) {
+	return r.under.Read(p)
+}
+
+func (r ringStream) snapshot() ([]byte, bool) {
+	return readStream(r.under), true
+}
+
+// RecentErrors tees ErrorStream through a [RingBufferTee] of size bytes,
+// and returns a function reporting the most recent of what has been written to it.
+func (e *Environment) RecentErrors(size int) func() []byte {
+	w, recent := RingBufferTee(e.ErrorStream, size)
+	e.ErrorStream = ringStream{w, e.ErrorStream}
+	return recent
+}
+
+// SplitInput copies InputStream to files of chunkSize bytes on the Filesystem, like `split -b`.
+// The files are named by formatting namePattern with a counter starting at 0, as in "part-%03d",
+// and their paths returned in order. The last file holds whatever is left over. Empty input makes no files.
+// A namePattern that doesn't vary with the counter is rejected before anything is written.
+// One chunk is copied at a time, so input is read no faster than it can be written.
+func (e Environment) SplitInput(chunkSize int64, namePattern string) ([]string, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("split: invalid chunk size %d", chunkSize)
+	}
+	//	a pattern without a verb formats as itself plus "%!(EXTRA int=0)", which does vary, so that is caught too
+	if first := fmt.Sprintf(namePattern, 0); first == fmt.Sprintf(namePattern, 1) || strings.Contains(first, "%!") {
+		return nil, fmt.Errorf("split: name pattern %q has no verb for the counter", namePattern)
+	}
+	in := bufio.NewReader(e.InputStream)
+	var paths []string
+	for i := 0; ; i++ {
+		if _, err := in.Peek(1); err == io.EOF {
+			return paths, nil
+		} else if err != nil {
+			return paths, err
+		}
+		name := e.ResolvePath(fmt.Sprintf(namePattern, i))
+		f, err := e.Filesystem.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, e.FileMode())
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, name)
+		_, err = io.CopyN(f, in, chunkSize)
+		if closeErr := f.Close(); err == nil || err == io.EOF {
+			err = closeErr
+		}
+		if err != nil {
+			return paths, err
+		}
+	}
+}
@@ -0,0 +1,604 @@
+package flargs_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_SetInputFiles(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem.WriteFile("one.txt", []byte("one\n"), 0644)
+	env.Filesystem.WriteFile("/two.txt", []byte("two\n"), 0644)
+	env.InputStream.Write([]byte("stdin\n"))
+
+	if err := env.SetInputFiles("one.txt", "-", "two.txt"); err != nil {
+		t.Fatal(err)
+	}
+	want := "one\nstdin\ntwo\n"
+	got := string(env.GetInput())
+	if got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+	if err := env.SetInputFiles("missing.txt"); err == nil {
+		t.Error("wanted an error for a missing file")
+	}
+
+}
+
+// openCountingFS is a [flargs.MemFS] that counts the files open on it
+type openCountingFS struct {
+	*flargs.MemFS
+	open *int
+}
+
+type countedFile struct {
+	fs.File
+	open *int
+}
+
+func (c countedFile) Close() error {
+	*c.open--
+	return c.File.Close()
+}
+
+func (o openCountingFS) Open(name string) (fs.File, error) {
+	f, err := o.MemFS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	*o.open++
+	return countedFile{f, o.open}, nil
+}
+
+func TestEnvironment_SetInputFiles_closes(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	open := 0
+	fsys := openCountingFS{flargs.NewMemFS(), &open}
+	env.Filesystem = fsys
+	fsys.WriteFile("/one.txt", []byte("one\n"), 0644)
+	fsys.WriteFile("/two.txt", []byte("two\n"), 0644)
+
+	if err := env.SetInputFiles("one.txt", "two.txt", "missing.txt"); err == nil {
+		t.Fatal("wanted an error for a missing file")
+	}
+	if open != 0 {
+		t.Errorf("got %d files left open after a failure", open)
+	}
+
+	cmd := flargs.EchoInput()
+	cmd.Environment = env
+	if err := env.SetInputFiles("one.txt", "two.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if code := cmd.Execute(nil); code != flargs.ExitCodeSuccess {
+		t.Fatalf("got exit code %d", code)
+	}
+	if got, want := env.OutputString(), "one\ntwo\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if open != 0 {
+		t.Errorf("got %d files left open after Execute", open)
+	}
+
+}
+
+func TestEnvironment_Fork(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	const children = 8
+
+	var wg sync.WaitGroup
+	for i := range children {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			child, merge := env.Fork()
+			for range 100 {
+				fmt.Fprintf(child.OutputStream, "%d", i)
+			}
+			fmt.Fprintln(child.OutputStream)
+			fmt.Fprintf(child.ErrorStream, "child %d done\n", i)
+			if err := merge(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSpace(string(env.GetOutput())), "\n")
+	if len(lines) != children {
+		t.Fatalf("got %d lines but wanted %d", len(lines), children)
+	}
+	for _, line := range lines {
+		if line != strings.Repeat(line[:1], 100) {
+			t.Errorf("got interleaved line %q", line)
+		}
+	}
+	if got := strings.Count(string(env.GetError()), "done"); got != children {
+		t.Errorf("got %d error lines but wanted %d", got, children)
+	}
+
+}
+
+func TestEnvironment_LimitedInput(t *testing.T) {
+
+	t.Run("over the limit", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write([]byte("0123456789"))
+		_, err := io.ReadAll(env.LimitedInput(5))
+		if !errors.Is(err, flargs.ErrInputTooLarge) {
+			t.Errorf("got %v but wanted ErrInputTooLarge", err)
+		}
+	})
+
+	t.Run("exactly the limit", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write([]byte("01234"))
+		got, err := io.ReadAll(env.LimitedInput(5))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "01234" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+}
+
+func TestEnvironment_AutoDecompressInput(t *testing.T) {
+
+	var zipped bytes.Buffer
+	zw := gzip.NewWriter(&zipped)
+	zw.Write([]byte("squashed\n"))
+	zw.Close()
+
+	for name, input := range map[string][]byte{
+		"gzip":  zipped.Bytes(),
+		"plain": []byte("squashed\n"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			env.InputStream.Write(input)
+			r, err := env.AutoDecompressInput()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "squashed\n"; string(got) != want {
+				t.Errorf("got %q but wanted %q", got, want)
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		r, err := env.AutoDecompressInput()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := io.ReadAll(r); len(got) != 0 {
+			t.Errorf("got %q but wanted nothing", got)
+		}
+	})
+
+}
+
+func TestEnvironment_OutputFile(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	if _, ok := env.InputFile(); ok {
+		t.Error("buffer-backed InputStream reported a file")
+	}
+	if _, ok := env.OutputFile(); ok {
+		t.Error("buffer-backed OutputStream reported a file")
+	}
+	if _, ok := env.ErrorFile(); ok {
+		t.Error("buffer-backed ErrorStream reported a file")
+	}
+
+	cli := flargs.NewCLIEnvironment("/")
+	if f, ok := cli.ErrorFile(); !ok || f != os.Stderr {
+		t.Errorf("got %v, %v but wanted os.Stderr", f, ok)
+	}
+
+}
+
+func TestEnvironment_ReadFromFD(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.SetFD(3, strings.NewReader("hunter2"))
+	secret, err := env.ReadFromFD(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(secret), "hunter2"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if _, err := env.ReadFromFD(4); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v but wanted %v", err, os.ErrNotExist)
+	}
+
+	t.Run("stdin stays open", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+		stdin := os.Stdin
+		os.Stdin = r
+		defer func() { os.Stdin = stdin }()
+		w.WriteString("hunter2")
+		w.Close()
+
+		env := flargs.NewTestingEnvironment(nil)
+		env.Variables["FLARGS_EXE_ENVIRONMENT"] = "cli"
+		secret, err := env.ReadFromFD(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(secret), "hunter2"; got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+		if _, err := r.Stat(); err != nil {
+			t.Errorf("stdin was closed: %v", err)
+		}
+	})
+
+}
+
+func TestSyncWriter(t *testing.T) {
+
+	env := flargs.NewConcurrentTestingEnvironment(nil)
+	const writers, lines = 8, 200
+
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			line := []byte(strings.Repeat(fmt.Sprint(i), 40) + "\n")
+			for range lines {
+				env.OutputStream.Write(line)
+			}
+		}()
+	}
+	wg.Wait()
+
+	got := strings.Split(strings.TrimSpace(string(env.GetOutput())), "\n")
+	if len(got) != writers*lines {
+		t.Fatalf("got %d lines but wanted %d", len(got), writers*lines)
+	}
+	for _, line := range got {
+		if line != strings.Repeat(line[:1], 40) {
+			t.Fatalf("got torn line %q", line)
+		}
+	}
+
+}
+
+func TestCallbackWriter(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	var chunks []string
+	env.OutputStream = flargs.CallbackWriter(func(p []byte) error {
+		if string(p) == "stop" {
+			return errors.New("host is gone")
+		}
+		chunks = append(chunks, string(p))
+		return nil
+	}).(io.ReadWriter)
+
+	fmt.Fprint(env.OutputStream, "hello")
+	fmt.Fprint(env.OutputStream, "world")
+	if len(chunks) != 2 || chunks[0] != "hello" || chunks[1] != "world" {
+		t.Errorf("got chunks %q", chunks)
+	}
+	if _, err := fmt.Fprint(env.OutputStream, "stop"); err == nil {
+		t.Error("wanted the callback error to surface from Write")
+	}
+
+}
+
+func TestEnvironment_HeadBytes(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.InputStream.Write([]byte("all your base are belong to us"))
+	got, err := env.HeadBytes(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "all your" {
+		t.Errorf("got %q", got)
+	}
+
+}
+
+func TestEnvironment_TailBytes(t *testing.T) {
+
+	table := map[int64]string{
+		5:   "to us",
+		30:  "all your base are belong to us",
+		100: "all your base are belong to us",
+		0:   "",
+	}
+	for n, want := range table {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write([]byte("all your base are belong to us"))
+		got, err := env.TailBytes(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("TailBytes(%d): got %q but wanted %q", n, got, want)
+		}
+	}
+
+	t.Run("huge n", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write([]byte("abc"))
+		got, err := env.TailBytes(1 << 40)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "abc" {
+			t.Errorf("got %q but wanted %q", got, "abc")
+		}
+	})
+
+	t.Run("across chunks", func(t *testing.T) {
+		var input strings.Builder
+		for i := range 20000 {
+			fmt.Fprintf(&input, "%d,", i)
+		}
+		for _, n := range []int64{1, 7, 4096, 50000, 200000} {
+			env := flargs.NewTestingEnvironment(nil)
+			env.InputStream.Write([]byte(input.String()))
+			got, err := env.TailBytes(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := input.String()
+			if int64(len(want)) > n {
+				want = want[int64(len(want))-n:]
+			}
+			if string(got) != want {
+				t.Errorf("TailBytes(%d): got %d bytes ending %q", n, len(got), got[max(len(got)-10, 0):])
+			}
+		}
+	})
+
+}
+
+func TestEnvironment_Copy(t *testing.T) {
+
+	large := bytes.Repeat([]byte("flargs "), 100_000)
+
+	t.Run("everything", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.InputStream.Write(large)
+		n, err := env.Copy(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(len(large)) || !bytes.Equal(env.GetOutput(), large) {
+			t.Errorf("copied %d of %d bytes", n, len(large))
+		}
+	})
+
+	t.Run("canceled mid-copy", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		ctx, cancel := context.WithCancel(context.Background())
+		//	cancel as soon as the first chunk has been read
+		env.InputStream = struct {
+			io.Reader
+			io.Writer
+		}{
+			io.TeeReader(bytes.NewReader(large), flargs.CallbackWriter(func([]byte) error {
+				cancel()
+				return nil
+			})),
+			io.Discard,
+		}
+		n, err := env.Copy(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v but wanted context.Canceled", err)
+		}
+		if n == 0 || n >= int64(len(large)) {
+			t.Errorf("copied %d bytes but wanted a partial copy", n)
+		}
+	})
+
+}
+
+func TestEnvironment_InputChannel(t *testing.T) {
+
+	t.Run("chunks then EOF", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		pr, pw := io.Pipe()
+		env.InputStream = struct {
+			io.Reader
+			io.Writer
+		}{pr, pw}
+		data, errc := env.InputChannel(nil)
+		for _, chunk := range []string{"first", "second"} {
+			go pw.Write([]byte(chunk))
+			if got := string(<-data); got != chunk {
+				t.Errorf("got %q but wanted %q", got, chunk)
+			}
+		}
+		pw.Close()
+		if chunk, open := <-data; open {
+			t.Errorf("got %q after EOF but wanted the channel closed", chunk)
+		}
+		if err := <-errc; err != nil {
+			t.Errorf("got %v but wanted no error at EOF", err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		pr, pw := io.Pipe()
+		defer pw.Close()
+		env.InputStream = struct {
+			io.Reader
+			io.Writer
+		}{pr, pw}
+		ctx, cancel := context.WithCancel(context.Background())
+		data, errc := env.InputChannel(ctx)
+		cancel()
+		if _, open := <-data; open {
+			t.Error("wanted the channel closed once cancelled")
+		}
+		if err := <-errc; !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v but wanted %v", err, context.Canceled)
+		}
+	})
+
+}
+
+func TestEnvironment_PushOutput(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fmt.Fprint(env.OutputStream, "before;")
+
+	outer := new(bytes.Buffer)
+	restoreOuter := env.PushOutput(outer)
+	fmt.Fprint(env.OutputStream, "outer;")
+	inner := new(bytes.Buffer)
+	restoreInner := env.PushOutput(inner)
+	fmt.Fprint(env.OutputStream, "inner")
+	restoreInner()
+	fmt.Fprint(env.OutputStream, "outer again")
+	restoreOuter()
+	fmt.Fprint(env.OutputStream, "after")
+
+	if got, want := inner.String(), "inner"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if got, want := outer.String(), "outer;outer again"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if got, want := env.OutputString(), "before;after"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestEnvironment_RedirectErrorToOutput(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fmt.Fprint(env.ErrorStream, "before;")
+	env.RedirectErrorToOutput()
+	env.RedirectErrorToOutput()
+	fmt.Fprint(env.OutputStream, "out;")
+	fmt.Fprint(env.ErrorStream, "err;")
+	env.RestoreError()
+	env.RestoreError()
+	fmt.Fprint(env.ErrorStream, "after")
+
+	if got, want := env.OutputString(), "out;err;"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if got, want := string(env.GetError()), "before;after"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestRingBufferTee(t *testing.T) {
+
+	var all bytes.Buffer
+	w, recent := flargs.RingBufferTee(&all, 8)
+	fmt.Fprint(w, "abc")
+	if got := string(recent()); got != "abc" {
+		t.Errorf("got %q but wanted %q", got, "abc")
+	}
+	fmt.Fprint(w, "defgh")
+	fmt.Fprint(w, "ijk")
+	if got, want := string(recent()), "defghijk"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	fmt.Fprint(w, "0123456789")
+	if got, want := string(recent()), "23456789"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if got, want := all.String(), "abcdefghijk0123456789"; got != want {
+		t.Errorf("got %q written through but wanted %q", got, want)
+	}
+
+}
+
+func TestEnvironment_RecentErrors(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	recent := env.RecentErrors(6)
+	fmt.Fprint(env.ErrorStream, "warning: one\nwarning: two\n")
+	if got, want := string(recent()), ": two\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if got, want := string(env.GetError()), "warning: one\nwarning: two\n"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestEnvironment_SplitInput(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	fmt.Fprint(env.InputStream, "abcdefghij")
+
+	paths, err := env.SplitInput(4, "/out/part-%02d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"/out/part-00": "abcd",
+		"/out/part-01": "efgh",
+		"/out/part-02": "ij",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("got paths %q but wanted %d of them", paths, len(want))
+	}
+	for i, p := range paths {
+		data, err := env.Filesystem.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want[p] {
+			t.Errorf("chunk %d: got %s=%q but wanted %q", i, p, data, want[p])
+		}
+	}
+
+	env.InputStream = new(bytes.Buffer)
+	if paths, err := env.SplitInput(4, "/empty-%d"); err != nil || len(paths) != 0 {
+		t.Errorf("got %q and %v for empty input but wanted no files", paths, err)
+	}
+
+	env.InputStream = bytes.NewBufferString("abcdefghij")
+	for _, pattern := range []string{"/same/part", "/same/part-%[2]d", "/same/part-%s"} {
+		if _, err := env.SplitInput(4, pattern); err == nil {
+			t.Errorf("%s: wanted an error for a name pattern without a usable verb", pattern)
+		}
+	}
+	if entries, _ := env.Filesystem.ReadDir("/same"); len(entries) != 0 {
+		t.Errorf("got %d files written for a bad name pattern", len(entries))
+	}
+
+}
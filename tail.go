@@ -0,0 +1,61 @@
+package flargs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// tailPollInterval is how often [Environment.Tail] checks for appended content
+const tailPollInterval = 250 * time.Millisecond
+
+// Tail follows path like `tail -f`. It starts at the current end of the file
+// and writes whatever is appended to out, polling on the [Clock], until ctx is done.
+// The file is kept open, so each poll reads only what is new.
+// If the file shrinks, it is assumed to have been truncated and is followed from the start.
+// Tail returns nil when ctx is canceled. A nil ctx means the Environment's Context.
+func (e Environment) Tail(ctx context.Context, path string, out io.Writer) error {
+	ctx = e.ctxOr(ctx)
+	path = e.ResolvePath(path)
+	f, err := e.Filesystem.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+	offset, err := skipToEnd(f)
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-e.Clock.After(tailPollInterval):
+		}
+		info, err := e.Filesystem.Stat(path)
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			f.Close()
+			if f, err = e.Filesystem.Open(path); err != nil {
+				return err
+			}
+			offset = 0
+		}
+		n, err := io.Copy(out, f)
+		offset += n
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// skipToEnd moves f to its end, by seeking if it can, and returns the offset it is now at
+func skipToEnd(f fs.File) (int64, error) {
+	if s, ok := f.(io.Seeker); ok {
+		return s.Seek(0, io.SeekEnd)
+	}
+	return io.Copy(io.Discard, f)
+}
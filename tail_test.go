@@ -0,0 +1,90 @@
+package flargs_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+// chanWriter hands each write to a channel, so a test can wait for output from another goroutine
+type chanWriter chan string
+
+func (c chanWriter) Write(p []byte) (int, error) {
+	c <- string(p)
+	return len(p), nil
+}
+
+// pollingClock polls quickly, and announces each time someone starts waiting on it
+type pollingClock struct {
+	flargs.SystemClock
+	waiting chan struct{}
+}
+
+func (c pollingClock) After(_ time.Duration) <-chan time.Time {
+	select {
+	case c.waiting <- struct{}{}:
+	default:
+	}
+	return time.After(time.Millisecond)
+}
+
+func TestEnvironment_Tail(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	clock := pollingClock{waiting: make(chan struct{})}
+	env.Clock = clock
+	env.Filesystem.WriteFile("app.log", []byte("old line\n"), 0644)
+	trace := new(bytes.Buffer)
+	env.Filesystem = flargs.TracingFS(env.Filesystem, trace)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chanWriter, 8)
+	done := make(chan error)
+	go func() {
+		done <- env.Tail(ctx, "app.log", out)
+	}()
+
+	//	wait until Tail has read the file and started polling
+	<-clock.waiting
+
+	f, err := env.Filesystem.OpenFile("app.log", os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("new line\n"))
+	f.Close()
+
+	select {
+	case got := <-out:
+		if got != "new line\n" {
+			t.Errorf("got %q but wanted only the appended line", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for appended content")
+	}
+
+	//	truncated and rewritten: followed from the start
+	env.Filesystem.WriteFile("app.log", []byte("fresh\n"), 0644)
+	select {
+	case got := <-out:
+		if got != "fresh\n" {
+			t.Errorf("got %q but wanted the rewritten file", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the truncated file")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+	if strings.Contains(trace.String(), "ReadFile") {
+		t.Errorf("Tail re-read the whole file:\n%s", trace)
+	}
+
+}
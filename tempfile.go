@@ -0,0 +1,66 @@
+package flargs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	rfs "github.com/sean9999/go-real-fs"
+)
+
+// maxTempAttempts is how many names [Environment.CreateTemp] tries before giving up
+const maxTempAttempts = 100
+
+// tempDir is TMPDIR, or /tmp
+func (e Environment) tempDir() string {
+	if dir := e.Variables["TMPDIR"]; dir != "" {
+		return dir
+	}
+	return "/tmp"
+}
+
+// CreateTemp is like [os.CreateTemp], but on the Filesystem, with names drawn from Randomness.
+// The last "*" in pattern is replaced by a random string. An empty dir means TMPDIR, or /tmp.
+// Under a seeded source the names are reproducible, so a name that's already taken
+// is skipped in favour of the next one drawn, rather than overwritten.
+// Its permissions are [Environment.FileMode], restricted to the owner.
+// In a [Environment.DryRun], it says what it would create, and the file it returns lives in a throwaway [MemFS],
+// so the Filesystem is not touched.
+func (e Environment) CreateTemp(dir, pattern string) (rfs.WritableFile, error) {
+	if e.DryRun() {
+		e.Filesystem = NewMemFS()
+	}
+	f, name, err := e.createTemp(dir, pattern, e.FileMode()&0600)
+	if err == nil && e.DryRun() {
+		e.dryRun("create %s", name)
+	}
+	return f, err
+}
+
+// createTemp does the work of [Environment.CreateTemp], with the given permissions, and also returns the name used
+func (e Environment) createTemp(dir, pattern string, perm fs.FileMode) (rfs.WritableFile, string, error) {
+	if dir == "" {
+		dir = e.tempDir()
+	}
+	if strings.ContainsRune(pattern, '/') {
+		return nil, "", &fs.PathError{Op: "createtemp", Path: pattern, Err: errors.New("pattern contains path separator")}
+	}
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndexByte(pattern, '*'); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	r := e.rand()
+	for range maxTempAttempts {
+		name := path.Join(e.ResolvePath(dir), prefix+strconv.FormatUint(uint64(r.Uint32()), 10)+suffix)
+		f, err := e.Filesystem.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return f, name, err
+	}
+	return nil, "", &fs.PathError{Op: "createtemp", Path: path.Join(dir, pattern), Err: fmt.Errorf("no free name after %d attempts", maxTempAttempts)}
+}
@@ -0,0 +1,37 @@
+package flargs_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_CreateTemp(t *testing.T) {
+
+	//	learn the first two names a seeded environment draws
+	probe := flargs.NewDeterministicEnvironment(5)
+	first, err := probe.CreateTemp("", "flargs-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := probe.CreateTemp("", "flargs-*.txt")
+	if !strings.HasPrefix(first.Name(), "/tmp/flargs-") || !strings.HasSuffix(first.Name(), ".txt") {
+		t.Errorf("got unexpected name %q", first.Name())
+	}
+
+	//	the same seed, with the first name already taken
+	env := flargs.NewDeterministicEnvironment(5)
+	env.Filesystem.WriteFile(first.Name(), []byte("precious"), 0644)
+	got, err := env.CreateTemp("", "flargs-*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name() != second.Name() {
+		t.Errorf("got %q but wanted %q", got.Name(), second.Name())
+	}
+	if data, _ := env.Filesystem.ReadFile(first.Name()); string(data) != "precious" {
+		t.Errorf("existing file was overwritten with %q", data)
+	}
+
+}
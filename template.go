@@ -0,0 +1,27 @@
+package flargs
+
+import (
+	"path"
+	"text/template"
+)
+
+// TemplateContext is what a template rendered by [Environment.RenderTemplate] sees as its dot
+type TemplateContext struct {
+	Env  map[string]string
+	Data any
+}
+
+// RenderTemplate parses the named template file from the Filesystem and executes it to OutputStream.
+// A relative name is resolved against WorkingDir.
+// Inside the template, {{.Env.NAME}} is a variable and {{.Data}} is data.
+func (e Environment) RenderTemplate(name string, data any) error {
+	text, err := e.Filesystem.ReadFile(e.ResolvePath(name))
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New(path.Base(e.CleanPath(name))).Parse(string(text))
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(e.OutputStream, TemplateContext{Env: e.Variables, Data: data})
+}
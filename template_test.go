@@ -0,0 +1,40 @@
+package flargs_test
+
+import (
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_RenderTemplate(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Variables["USER"] = "robin"
+	env.Filesystem.WriteFile("templates/greeting.tmpl", []byte("hello, {{.Env.USER}}. you have {{.Data}} messages."), 0644)
+
+	if err := env.RenderTemplate("templates/greeting.tmpl", 3); err != nil {
+		t.Fatal(err)
+	}
+	want := "hello, robin. you have 3 messages."
+	if got := string(env.GetOutput()); got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+	if err := env.RenderTemplate("templates/missing.tmpl", nil); err == nil {
+		t.Error("wanted an error for a missing template")
+	}
+
+	if err := env.Chdir("templates"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"greeting.tmpl", "/templates/greeting.tmpl"} {
+		env.TruncateOutput()
+		if err := env.RenderTemplate(name, 3); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if got := string(env.GetOutput()); got != want {
+			t.Errorf("%s: got %q but wanted %q", name, got, want)
+		}
+	}
+
+}
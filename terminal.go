@@ -0,0 +1,86 @@
+package flargs
+
+import (
+	"bytes"
+	"os"
+	"strconv"
+)
+
+// IsTerminal reports whether OutputStream is a terminal.
+// Buffers and pipes are not terminals.
+func (e Environment) IsTerminal() bool {
+	f, ok := e.OutputFile()
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// defaultWidth is the width assumed for anything that isn't a terminal
+const defaultWidth = 80
+
+// TerminalWidth is the width of the terminal OutputStream is, as the terminal reports it.
+// A positive COLUMNS overrides that, whatever OutputStream is.
+// Otherwise, anything that isn't a terminal is 80 wide, so output written to files and buffers doesn't vary.
+func (e Environment) TerminalWidth() int {
+	if cols, err := strconv.Atoi(e.Variables["COLUMNS"]); err == nil && cols > 0 {
+		return cols
+	}
+	if f, ok := e.OutputFile(); ok && e.IsTerminal() {
+		if cols, ok := terminalColumns(f); ok {
+			return cols
+		}
+	}
+	return defaultWidth
+}
+
+// ColorEnabled decides whether output should be colourized.
+// A non-empty NO_COLOR wins, then a non-empty FORCE_COLOR, and otherwise colour follows [Environment.IsTerminal].
+// Set to the empty string, either one counts as unset.
+// See https://no-color.org and https://force-color.org.
+func (e Environment) ColorEnabled() bool {
+	if e.Variables["NO_COLOR"] != "" {
+		return false
+	}
+	if e.Variables["FORCE_COLOR"] != "" {
+		return true
+	}
+	return e.IsTerminal()
+}
+
+// Colorize wraps s in the ANSI SGR sequence sgr (ex: "31" for red, "1;32" for bold green),
+// but only when [Environment.ColorEnabled]. Otherwise s is returned as is.
+func (e Environment) Colorize(sgr string, s string) string {
+	if !e.ColorEnabled() {
+		return s
+	}
+	return "\x1b[" + sgr + "m" + s + "\x1b[0m"
+}
+
+// InputIsPiped reports whether input is coming from somewhere other than a terminal,
+// so a command can decide between reading InputStream and prompting.
+// An [os.File] is piped when it is not a terminal. A buffer, as in tests, is piped when it has unread content.
+// Any other stream is assumed to be piped.
+func (e Environment) InputIsPiped() bool {
+	switch s := e.InputStream.(type) {
+	case *os.File:
+		info, err := s.Stat()
+		if err != nil {
+			return false
+		}
+		return info.Mode()&os.ModeCharDevice == 0
+	case *bytes.Buffer:
+		return s.Len() > 0
+	case NullDevice, nil:
+		return false
+	case snapshotter:
+		if buf, ok := s.snapshot(); ok {
+			return len(buf) > 0
+		}
+	}
+	return true
+}
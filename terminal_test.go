@@ -0,0 +1,97 @@
+package flargs_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_ColorEnabled(t *testing.T) {
+
+	table := []struct {
+		name string
+		vars map[string]string
+		want bool
+	}{
+		{"buffer defers to IsTerminal", nil, false},
+		{"FORCE_COLOR", map[string]string{"FORCE_COLOR": "1"}, true},
+		{"NO_COLOR", map[string]string{"NO_COLOR": "1"}, false},
+		{"NO_COLOR beats FORCE_COLOR", map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"}, false},
+		{"empty NO_COLOR is unset", map[string]string{"NO_COLOR": "", "FORCE_COLOR": "1"}, true},
+		{"empty FORCE_COLOR is unset", map[string]string{"FORCE_COLOR": ""}, false},
+	}
+
+	for _, row := range table {
+		t.Run(row.name, func(t *testing.T) {
+			env := flargs.NewTestingEnvironment(nil)
+			for k, v := range row.vars {
+				env.Variables[k] = v
+			}
+			if got := env.ColorEnabled(); got != row.want {
+				t.Errorf("got %v but wanted %v", got, row.want)
+			}
+		})
+	}
+
+}
+
+func TestEnvironment_Colorize(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	if got := env.Colorize("31", "red"); got != "red" {
+		t.Errorf("got %q but wanted plain text", got)
+	}
+	env.Variables["FORCE_COLOR"] = "1"
+	if got, want := env.Colorize("31", "red"), "\x1b[31mred\x1b[0m"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestEnvironment_InputIsPiped(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	if env.InputIsPiped() {
+		t.Error("empty input was reported as piped")
+	}
+	fmt.Fprint(env.InputStream, "data\n")
+	if !env.InputIsPiped() {
+		t.Error("buffered input was not reported as piped")
+	}
+	if got := string(env.GetInput()); got != "data\n" {
+		t.Errorf("InputIsPiped consumed the input, leaving %q", got)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	env.InputStream = r
+	if !env.InputIsPiped() {
+		t.Error("an os.Pipe was not reported as piped")
+	}
+
+}
+
+func TestEnvironment_TerminalWidth(t *testing.T) {
+
+	for columns, want := range map[string]int{
+		"132":  132,
+		"40":   40,
+		"":     80,
+		"0":    80,
+		"-5":   80,
+		"wide": 80,
+	} {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Variables["COLUMNS"] = columns
+		if got := env.TerminalWidth(); got != want {
+			t.Errorf("COLUMNS=%q: got %d but wanted %d", columns, got, want)
+		}
+	}
+
+}
@@ -0,0 +1,108 @@
+package flargs
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+
+	rfs "github.com/sean9999/go-real-fs"
+)
+
+// tracingFS is an [rfs.WritableFs] that logs every call it passes through
+type tracingFS struct {
+	fsys rfs.WritableFs
+	mu   *sync.Mutex
+	log  io.Writer
+}
+
+// tracingRenamerFS is a tracingFS over a Filesystem that can rename, so wrapping doesn't hide that it can
+type tracingRenamerFS struct {
+	tracingFS
+}
+
+// TracingFS wraps fsys so that every call writes a line to log, naming the method, the path and the result:
+//
+//	fs: WriteFile "/notes.txt": ok
+//	fs: Open "/missing.txt": open /missing.txt: file does not exist
+//
+// Lines are written whole, even when fsys is used from several goroutines.
+// The result has an Unwrap method that returns fsys, so [Environment.SetClock] and [Environment.SnapshotFS] see through it.
+func TracingFS(fsys rfs.WritableFs, log io.Writer) rfs.WritableFs {
+	t := tracingFS{fsys: fsys, mu: new(sync.Mutex), log: log}
+	if _, ok := fsys.(renamer); ok {
+		return tracingRenamerFS{t}
+	}
+	return t
+}
+
+// TraceFilesystem wraps the Filesystem in a [TracingFS] that writes to ErrorStream
+func (e *Environment) TraceFilesystem() {
+	e.Filesystem = TracingFS(e.Filesystem, e.ErrorStream)
+}
+
+func (t tracingFS) Unwrap() rfs.WritableFs {
+	return t.fsys
+}
+
+func (t tracingFS) trace(method string, err error, paths ...string) {
+	result := "ok"
+	if err != nil {
+		result = err.Error()
+	}
+	line := "fs: " + method
+	for _, p := range paths {
+		line += fmt.Sprintf(" %q", p)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.log, "%s: %s\n", line, result)
+}
+
+func (t tracingFS) Open(name string) (fs.File, error) {
+	f, err := t.fsys.Open(name)
+	t.trace("Open", err, name)
+	return f, err
+}
+
+func (t tracingFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := t.fsys.Stat(name)
+	t.trace("Stat", err, name)
+	return info, err
+}
+
+func (t tracingFS) ReadFile(name string) ([]byte, error) {
+	data, err := t.fsys.ReadFile(name)
+	t.trace("ReadFile", err, name)
+	return data, err
+}
+
+func (t tracingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := t.fsys.ReadDir(name)
+	t.trace("ReadDir", err, name)
+	return entries, err
+}
+
+func (t tracingFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	err := t.fsys.WriteFile(name, data, perm)
+	t.trace("WriteFile", err, name)
+	return err
+}
+
+func (t tracingFS) OpenFile(name string, flag int, perm fs.FileMode) (rfs.WritableFile, error) {
+	f, err := t.fsys.OpenFile(name, flag, perm)
+	t.trace("OpenFile", err, name)
+	return f, err
+}
+
+func (t tracingFS) Remove(name string) error {
+	err := t.fsys.Remove(name)
+	t.trace("Remove", err, name)
+	return err
+}
+
+func (t tracingRenamerFS) Rename(oldname, newname string) error {
+	err := t.fsys.(renamer).Rename(oldname, newname)
+	t.trace("Rename", err, oldname, newname)
+	return err
+}
@@ -0,0 +1,67 @@
+package flargs_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_TraceFilesystem(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.TraceFilesystem()
+
+	if err := env.Filesystem.WriteFile("/notes.txt", []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := env.Filesystem.ReadFile("/notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	env.Filesystem.ReadFile("/missing.txt")
+
+	want := "fs: WriteFile \"/notes.txt\": ok\n" +
+		"fs: ReadFile \"/notes.txt\": ok\n" +
+		"fs: ReadFile \"/missing.txt\": read /missing.txt: file does not exist\n"
+	if got := string(env.GetError()); got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestTracingFS_keepsRename(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.TraceFilesystem()
+	if err := env.WriteFileAtomic("/a.txt", []byte("atomic")); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(env.GetError()); !strings.Contains(got, "fs: Rename ") {
+		t.Errorf("wanted the atomic write to rename, got trace %q", got)
+	}
+
+}
+
+func TestTracingFS_unwrap(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	mem := env.Filesystem.(*flargs.MemFS)
+	env.TraceFilesystem()
+
+	epoch := time.Unix(0, 0).UTC()
+	env.SetClock(flargs.FixedClock{Time: epoch})
+	if mem.Clock != env.Clock {
+		t.Error("SetClock did not reach the MemFS under the TracingFS")
+	}
+	env.Filesystem.WriteFile("/a.txt", []byte("a"), 0644)
+	snap, err := env.SnapshotFS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := snap.Files(), []string{"/a.txt"}; !slices.Equal(got, want) {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
@@ -0,0 +1,274 @@
+package flargs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidVarName is returned by [Environment.SetVarSafe] for a name that isn't a valid environment variable name
+var ErrInvalidVarName = errors.New("invalid variable name")
+
+// ErrVarNotSet is returned by the typed getters, such as [Environment.GetDuration], when a variable is not set
+var ErrVarNotSet = errors.New("variable not set")
+
+// a VarSource resolves variables that aren't in an Environment's Variables,
+// from somewhere like Consul or Vault
+type VarSource interface {
+	Lookup(key string) (string, bool)
+}
+
+// AddVarSource registers s with the Environment. Sources are consulted in the order they were added.
+func (e *Environment) AddVarSource(s VarSource) {
+	e.varSources = append(e.varSources, s)
+}
+
+// LookupVar looks key up in Variables, then in each [VarSource] in turn
+func (e Environment) LookupVar(key string) (string, bool) {
+	if v, exists := e.Variables[key]; exists {
+		return v, true
+	}
+	for _, src := range e.varSources {
+		if v, exists := src.Lookup(key); exists {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// ExpandVars replaces $VAR and ${VAR} in s with values from [Environment.LookupVar].
+// Unset variables expand to the empty string, as in a shell.
+func (e Environment) ExpandVars(s string) string {
+	return os.Expand(s, func(key string) string {
+		v, _ := e.LookupVar(key)
+		return v
+	})
+}
+
+// ResolveVars expands references between Variables until every value is stable,
+// so FULL=$BASE/suffix picks up whatever BASE itself resolves to.
+// A cyclic reference is reported as an error and leaves Variables unchanged.
+func (e *Environment) ResolveVars() error {
+	resolved := make(map[string]string, len(e.Variables))
+	visiting := map[string]bool{}
+
+	var resolve func(key string, chain []string) (string, error)
+	resolve = func(key string, chain []string) (string, error) {
+		if v, done := resolved[key]; done {
+			return v, nil
+		}
+		raw, exists := e.Variables[key]
+		if !exists {
+			return "", nil
+		}
+		if visiting[key] {
+			return "", fmt.Errorf("cyclic variable reference: %s", strings.Join(append(chain, key), " -> "))
+		}
+		visiting[key] = true
+		var err error
+		v := os.Expand(raw, func(ref string) string {
+			if err != nil {
+				return ""
+			}
+			var s string
+			s, err = resolve(ref, append(chain, key))
+			return s
+		})
+		visiting[key] = false
+		if err != nil {
+			return "", err
+		}
+		resolved[key] = v
+		return v, nil
+	}
+
+	keys := make([]string, 0, len(e.Variables))
+	for k := range e.Variables {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err := resolve(k, nil); err != nil {
+			return err
+		}
+	}
+	for k, v := range resolved {
+		e.Variables[k] = v
+	}
+	return nil
+}
+
+// ChildEnviron returns Variables as a sorted KEY=VALUE slice, suitable for [os/exec.Cmd.Env].
+// Values in set are added or overwrite existing ones, then keys in unset are removed.
+func (e Environment) ChildEnviron(set map[string]string, unset ...string) []string {
+	merged := make(map[string]string, len(e.Variables)+len(set))
+	for k, v := range e.Variables {
+		merged[k] = v
+	}
+	for k, v := range set {
+		merged[k] = v
+	}
+	for _, k := range unset {
+		delete(merged, k)
+	}
+	environ := make([]string, 0, len(merged))
+	for k, v := range merged {
+		environ = append(environ, k+"="+v)
+	}
+	sort.Strings(environ)
+	return environ
+}
+
+// ResolveFileVars supports the KEY_FILE convention used for Docker and Kubernetes secrets.
+// For every KEY_FILE variable, the file it names is read from the Filesystem,
+// and KEY is set to its contents, trimmed of surrounding whitespace. A KEY that is already set is left alone.
+func (e *Environment) ResolveFileVars() error {
+	keys := make([]string, 0, len(e.Variables))
+	for k := range e.Variables {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		key, isFileVar := strings.CutSuffix(k, "_FILE")
+		if !isFileVar || key == "" {
+			continue
+		}
+		if _, set := e.Variables[key]; set {
+			continue
+		}
+		data, err := e.Filesystem.ReadFile(e.ResolvePath(e.Variables[k]))
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		e.Variables[key] = strings.TrimSpace(string(data))
+		e.tagVar(key, OriginFile)
+	}
+	return nil
+}
+
+// GetDuration parses the variable key as a [time.Duration], like "30s" or "1h15m"
+func (e Environment) GetDuration(key string) (time.Duration, error) {
+	v, exists := e.LookupVar(key)
+	if !exists {
+		return 0, fmt.Errorf("%s: %w", key, ErrVarNotSet)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(v))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return d, nil
+}
+
+// byteUnits maps lower-cased size suffixes to their multipliers.
+// KB, MB, etc. are decimal. KiB, MiB, etc. are binary.
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+}
+
+// GetBytes parses the variable key as a size in bytes, like "512", "10MB" or "1.5GiB".
+// Suffixes are case-insensitive.
+func (e Environment) GetBytes(key string) (int64, error) {
+	v, exists := e.LookupVar(key)
+	if !exists {
+		return 0, fmt.Errorf("%s: %w", key, ErrVarNotSet)
+	}
+	s := strings.TrimSpace(v)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	number, suffix := s[:i], strings.TrimSpace(s[i:])
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: invalid size %q", key, v)
+	}
+	unit, known := byteUnits[strings.ToLower(suffix)]
+	if !known {
+		return 0, fmt.Errorf("%s: unknown size suffix %q in %q", key, suffix, v)
+	}
+	size := n * unit
+	//	MaxInt64 rounds up to 2^63 as a float64, which is already out of range
+	if size >= math.MaxInt64 {
+		return 0, fmt.Errorf("%s: size %q is too large", key, v)
+	}
+	return int64(size), nil
+}
+
+// GetBool parses the variable key as a boolean. As well as what [strconv.ParseBool] accepts,
+// "yes", "on", "no" and "off" are understood, in any case.
+func (e Environment) GetBool(key string) (bool, error) {
+	v, exists := e.LookupVar(key)
+	if !exists {
+		return false, fmt.Errorf("%s: %w", key, ErrVarNotSet)
+	}
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "yes", "on":
+		return true, nil
+	case "no", "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		return false, fmt.Errorf("%s: invalid boolean %q", key, v)
+	}
+	return b, nil
+}
+
+// FeatureEnabled reports whether the feature flag name is on.
+// A FEATURE_<NAME> variable decides, if it is set to a boolean, as read by [Environment.GetBool].
+// Otherwise the feature is on if name appears in the comma-separated FLARGS_FEATURES.
+// Names are case-insensitive, and dashes match underscores, so "new-ui" is FEATURE_NEW_UI.
+func (e Environment) FeatureEnabled(name string) bool {
+	normal := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(name), "-", "_"))
+	if on, err := e.GetBool("FEATURE_" + normal); err == nil {
+		return on
+	}
+	list, _ := e.LookupVar("FLARGS_FEATURES")
+	for _, feature := range strings.Split(list, ",") {
+		if strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(feature), "-", "_")) == normal {
+			return true
+		}
+	}
+	return false
+}
+
+// validVarName reports whether key matches the POSIX pattern [A-Za-z_][A-Za-z0-9_]*
+func validVarName(key string) bool {
+	for i, r := range key {
+		switch {
+		case r == '_', r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return key != ""
+}
+
+// SetVarSafe is [Environment.SetVar] for names from untrusted sources, such as HTTP headers.
+// A name that doesn't match [A-Za-z_][A-Za-z0-9_]* is rejected with [ErrInvalidVarName], and nothing is set.
+func (e *Environment) SetVarSafe(key, value string) error {
+	if !validVarName(key) {
+		return fmt.Errorf("%w %q: must match [A-Za-z_][A-Za-z0-9_]*", ErrInvalidVarName, key)
+	}
+	e.SetVar(key, value)
+	return nil
+}
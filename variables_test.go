@@ -0,0 +1,274 @@
+package flargs_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_ResolveVars(t *testing.T) {
+
+	t.Run("chain", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Variables["ROOT"] = "/srv"
+		env.Variables["BASE"] = "$ROOT/app"
+		env.Variables["FULL"] = "${BASE}/suffix"
+		if err := env.ResolveVars(); err != nil {
+			t.Fatal(err)
+		}
+		want := "/srv/app/suffix"
+		if got := env.Variables["FULL"]; got != want {
+			t.Errorf("got %q but wanted %q", got, want)
+		}
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Variables["A"] = "$B"
+		env.Variables["B"] = "x$A"
+		err := env.ResolveVars()
+		if err == nil || !strings.Contains(err.Error(), "cyclic") {
+			t.Fatalf("got %v but wanted a cycle error", err)
+		}
+		if env.Variables["A"] != "$B" {
+			t.Errorf("variables were modified despite the error: A=%q", env.Variables["A"])
+		}
+	})
+
+}
+
+func TestEnvironment_ChildEnviron(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Variables = map[string]string{
+		"HOME":  "/home/robin",
+		"PATH":  "/bin",
+		"TOKEN": "secret",
+	}
+
+	got := env.ChildEnviron(map[string]string{"PATH": "/usr/bin", "LANG": "C"}, "TOKEN")
+	want := []string{"HOME=/home/robin", "LANG=C", "PATH=/usr/bin"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+	if env.Variables["PATH"] != "/bin" || env.Variables["TOKEN"] != "secret" {
+		t.Error("ChildEnviron modified the parent's variables")
+	}
+
+}
+
+// vault is a pretend remote secret store
+type vault map[string]string
+
+func (v vault) Lookup(key string) (string, bool) {
+	s, ok := v[key]
+	return s, ok
+}
+
+func TestEnvironment_LookupVar(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Variables["REGION"] = "local"
+	env.AddVarSource(vault{"DB_PASSWORD": "hunter2", "REGION": "remote"})
+	env.AddVarSource(vault{"DB_PASSWORD": "ignored", "DB_HOST": "db.internal"})
+
+	table := []struct {
+		key    string
+		want   string
+		exists bool
+	}{
+		{"REGION", "local", true},
+		{"DB_PASSWORD", "hunter2", true},
+		{"DB_HOST", "db.internal", true},
+		{"NOPE", "", false},
+	}
+	for _, row := range table {
+		got, exists := env.LookupVar(row.key)
+		if got != row.want || exists != row.exists {
+			t.Errorf("LookupVar(%q): got %q, %v but wanted %q, %v", row.key, got, exists, row.want, row.exists)
+		}
+	}
+
+	if got, want := env.ExpandVars("$DB_HOST:$REGION"), "db.internal:local"; got != want {
+		t.Errorf("got %q but wanted %q", got, want)
+	}
+
+}
+
+func TestEnvironment_ResolveFileVars(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Filesystem.WriteFile("/run/secrets/token", []byte("s3cr3t\n"), 0600)
+	env.Filesystem.WriteFile("/run/secrets/user", []byte("mallory\n"), 0600)
+	env.Variables["TOKEN_FILE"] = "/run/secrets/token"
+	env.Variables["USER"] = "robin"
+	env.Variables["USER_FILE"] = "/run/secrets/user"
+
+	if err := env.ResolveFileVars(); err != nil {
+		t.Fatal(err)
+	}
+	if got := env.Variables["TOKEN"]; got != "s3cr3t" {
+		t.Errorf("got %q but wanted %q", got, "s3cr3t")
+	}
+	if got := env.Variables["USER"]; got != "robin" {
+		t.Errorf("got %q but wanted %q", got, "robin")
+	}
+
+	env.Variables["MISSING_FILE"] = "/nowhere"
+	if err := env.ResolveFileVars(); err == nil || !strings.Contains(err.Error(), "MISSING_FILE") {
+		t.Errorf("got %v but wanted an error naming MISSING_FILE", err)
+	}
+
+}
+
+func TestEnvironment_GetBytes(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	for value, want := range map[string]int64{
+		"512":    512,
+		"1KB":    1000,
+		"1KiB":   1024,
+		"10mb":   10_000_000,
+		"1.5GiB": 3 << 29,
+		"2 MiB":  2 << 20,
+	} {
+		env.Variables["SIZE"] = value
+		got, err := env.GetBytes("SIZE")
+		if err != nil {
+			t.Errorf("%q: %v", value, err)
+		} else if got != want {
+			t.Errorf("%q: got %d but wanted %d", value, got, want)
+		}
+	}
+
+	for _, value := range []string{"10XB", "MB", "1.2.3KB", "8192PiB", "9223372036854775807"} {
+		env.Variables["SIZE"] = value
+		if _, err := env.GetBytes("SIZE"); err == nil {
+			t.Errorf("%q: wanted an error", value)
+		}
+	}
+
+	if _, err := env.GetBytes("UNSET"); !errors.Is(err, flargs.ErrVarNotSet) {
+		t.Errorf("got %v but wanted %v", err, flargs.ErrVarNotSet)
+	}
+
+}
+
+func TestEnvironment_GetDuration(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	env.Variables["TIMEOUT"] = "1m30s"
+	got, err := env.GetDuration("TIMEOUT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 90 * time.Second; got != want {
+		t.Errorf("got %s but wanted %s", got, want)
+	}
+	env.Variables["TIMEOUT"] = "soon"
+	if _, err := env.GetDuration("TIMEOUT"); err == nil {
+		t.Error("wanted an error for an invalid duration")
+	}
+
+}
+
+func TestEnvironment_GetBool(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	for value, want := range map[string]bool{"1": true, "TRUE": true, "yes": true, "On": true, "0": false, "false": false, "no": false, "off": false} {
+		env.Variables["FLAG"] = value
+		if got, err := env.GetBool("FLAG"); err != nil || got != want {
+			t.Errorf("%q: got %v, %v but wanted %v", value, got, err, want)
+		}
+	}
+	env.Variables["FLAG"] = "maybe"
+	if _, err := env.GetBool("FLAG"); err == nil {
+		t.Error("wanted an error for an invalid boolean")
+	}
+
+}
+
+func TestEnvironment_FeatureEnabled(t *testing.T) {
+
+	t.Run("variable", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Variables["FEATURE_NEW_UI"] = "true"
+		env.Variables["FEATURE_BETA"] = "off"
+		env.Variables["FLARGS_FEATURES"] = "beta"
+		if !env.FeatureEnabled("new-ui") {
+			t.Error("FEATURE_NEW_UI=true did not enable new-ui")
+		}
+		if env.FeatureEnabled("beta") {
+			t.Error("FEATURE_BETA=off did not win over FLARGS_FEATURES")
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		env := flargs.NewTestingEnvironment(nil)
+		env.Variables["FLARGS_FEATURES"] = "fast-path, Telemetry"
+		for name, want := range map[string]bool{"fast-path": true, "telemetry": true, "new-ui": false} {
+			if got := env.FeatureEnabled(name); got != want {
+				t.Errorf("%s: got %v but wanted %v", name, got, want)
+			}
+		}
+	})
+
+}
+
+func TestEnvironment_VarOrigin(t *testing.T) {
+
+	t.Setenv("FLARGS_TEST_INHERITED", "from the shell")
+	t.Setenv("FLARGS_TEST_CHANGED", "from the shell")
+	env := flargs.NewCLIEnvironment("/")
+	env.Filesystem = flargs.NewMemFS()
+	env.Filesystem.WriteFile("/app.json", []byte(`{"flargs_test": {"loaded": 1}}`), 0644)
+	if err := env.LoadConfig("/app.json"); err != nil {
+		t.Fatal(err)
+	}
+	env.SetVar("FLARGS_TEST_SET", "by the program")
+	env.Variables["FLARGS_TEST_CHANGED"] = "by the program"
+
+	for key, want := range map[string]flargs.Origin{
+		"FLARGS_TEST_INHERITED":  flargs.OriginInherited,
+		"FLARGS_TEST_CHANGED":    flargs.OriginSet,
+		"FLARGS_TEST_SET":        flargs.OriginSet,
+		"FLARGS_TEST_LOADED":     flargs.OriginFile,
+		"FLARGS_EXE_ENVIRONMENT": flargs.OriginSet,
+	} {
+		got, ok := env.VarOrigin(key)
+		if !ok || got != want {
+			t.Errorf("%s: got %s, %v but wanted %s", key, got, ok, want)
+		}
+	}
+	if _, ok := env.VarOrigin("FLARGS_TEST_UNSET"); ok {
+		t.Error("an unset variable has an origin")
+	}
+
+}
+
+func TestEnvironment_SetVarSafe(t *testing.T) {
+
+	env := flargs.NewTestingEnvironment(nil)
+	for _, key := range []string{"PATH", "_private", "x509_CERT"} {
+		if err := env.SetVarSafe(key, "ok"); err != nil {
+			t.Errorf("%q: %v", key, err)
+		}
+		if env.Variables[key] != "ok" {
+			t.Errorf("%q was not set", key)
+		}
+	}
+	for _, key := range []string{"X-Forwarded-For", "9LIVES", "", "A B", "CAFÉ"} {
+		err := env.SetVarSafe(key, "bad")
+		if !errors.Is(err, flargs.ErrInvalidVarName) {
+			t.Errorf("%q: got %v but wanted %v", key, err, flargs.ErrInvalidVarName)
+		}
+		if _, set := env.Variables[key]; set {
+			t.Errorf("%q was set despite being invalid", key)
+		}
+	}
+
+}
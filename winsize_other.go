@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package flargs
+
+import "os"
+
+// terminalColumns can't ask the terminal on this platform, so it never knows
+func terminalColumns(_ *os.File) (int, bool) {
+	return 0, false
+}
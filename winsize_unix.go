@@ -0,0 +1,31 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package flargs
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// winsize is the kernel's struct winsize, as filled in by TIOCGWINSZ
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+// terminalColumns asks the terminal behind f how many columns wide it is
+func terminalColumns(f *os.File) (int, bool) {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return 0, false
+	}
+	var ws winsize
+	var errno syscall.Errno
+	err = conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	})
+	if err != nil || errno != 0 || ws.cols == 0 {
+		return 0, false
+	}
+	return int(ws.cols), true
+}
@@ -0,0 +1,72 @@
+package flargs
+
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WriteWrapped reflows text to [Environment.TerminalWidth] and writes it to OutputStream, ending with a newline.
+// Each line of text is wrapped on its own, so hard line breaks and blank lines survive.
+// An indented line keeps its indentation, and a list item ("- ", "* ", "1. ") hangs its continuation lines under its first word.
+// A word longer than the width gets a line to itself.
+func (e Environment) WriteWrapped(text string) error {
+	_, err := io.WriteString(e.OutputStream, wrap(text, e.TerminalWidth()))
+	return err
+}
+
+// wrap reflows every line of text to width
+func wrap(text string, width int) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		wrapLine(&b, line, width)
+	}
+	return b.String()
+}
+
+// wrapLine writes one hard line to b, broken into lines no wider than width where possible
+func wrapLine(b *strings.Builder, line string, width int) {
+	body := strings.TrimLeftFunc(line, unicode.IsSpace)
+	indent := line[:len(line)-len(body)]
+	hanging := indent + strings.Repeat(" ", utf8.RuneCountInString(listMarker(body)))
+	words := strings.Fields(body)
+	if len(words) == 0 {
+		b.WriteString("\n")
+		return
+	}
+	b.WriteString(indent)
+	col := utf8.RuneCountInString(indent)
+	for i, word := range words {
+		n := utf8.RuneCountInString(word)
+		switch {
+		case i == 0:
+		case col+1+n > width:
+			b.WriteString("\n")
+			b.WriteString(hanging)
+			col = utf8.RuneCountInString(hanging)
+		default:
+			b.WriteString(" ")
+			col++
+		}
+		b.WriteString(word)
+		col += n
+	}
+	b.WriteString("\n")
+}
+
+// listMarker returns the bullet or number that starts a list item, with the space after it, or "" if s isn't one
+func listMarker(s string) string {
+	marker, _, found := strings.Cut(s, " ")
+	if !found {
+		return ""
+	}
+	switch {
+	case marker == "-", marker == "*", marker == "+":
+		return marker + " "
+	case len(marker) > 1 && strings.ContainsRune(".)", rune(marker[len(marker)-1])) &&
+		strings.TrimLeft(marker[:len(marker)-1], "0123456789") == "":
+		return marker + " "
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package flargs_test
+
+import (
+	"testing"
+
+	"github.com/sean9999/go-flargs"
+)
+
+func TestEnvironment_WriteWrapped(t *testing.T) {
+
+	text := "Kat concatenates files and prints them to standard output. With no file, or when a file is -, it reads standard input instead.\n" +
+		"\n" +
+		"Options:\n" +
+		"  - -n numbers every output line, counting from one, and keeps counting across every file it is given\n" +
+		"  10. a numbered item wraps under its own text rather than under its number, however long it runs on for\n"
+
+	want := "Kat concatenates files and prints them to standard output. With no file, or when\n" +
+		"a file is -, it reads standard input instead.\n" +
+		"\n" +
+		"Options:\n" +
+		"  - -n numbers every output line, counting from one, and keeps counting across\n" +
+		"    every file it is given\n" +
+		"  10. a numbered item wraps under its own text rather than under its number,\n" +
+		"      however long it runs on for\n"
+
+	env := flargs.NewTestingEnvironment(nil)
+	if err := env.WriteWrapped(text); err != nil {
+		t.Fatal(err)
+	}
+	if got := env.OutputString(); got != want {
+		t.Errorf("got:\n%s\nbut wanted:\n%s", got, want)
+	}
+
+}